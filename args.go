@@ -0,0 +1,277 @@
+package clistruct
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	argsTerminator = "--"
+	argRest        = "rest"
+)
+
+// argField is a struct field bound to the positional argument.
+type argField struct {
+	field reflect.StructField
+	value reflect.Value
+	index int
+	rest  bool
+}
+
+// ArgsUsageFromStruct generates a usage text for the positional
+// arguments bound to the struct fields with arg tag,
+// it could be used as ArgsUsage of the cli.App or cli.Command.
+// Required arguments are shown as `<name>`, arguments which has
+// default value (or are bound to pointers) as `[name]`
+// and the rest of arguments as `[name...]`.
+func ArgsUsageFromStruct(v interface{}, opts ...Option) (string, error) {
+	return defaultMapper.ArgsUsageFromStruct(v, opts...)
+}
+
+// ArgsUsageFromStruct is like ArgsUsageFromStruct function, but uses
+// the mapper options, opts are applied on top of them.
+func (m *Mapper) ArgsUsageFromStruct(v interface{}, opts ...Option) (string, error) {
+	err := checkValue(v)
+	if err != nil {
+		return "", err
+	}
+
+	return argsUsageFromStruct(v, m.options.with(opts))
+}
+
+func argsUsageFromStruct(v interface{}, o *options) (string, error) {
+	var (
+		usage []string
+	)
+
+	fields, err := argFieldsFromStruct(v, o)
+	if err != nil {
+		return "", err
+	}
+
+	for _, arg := range fields {
+		name := argNameFromStructField(arg.field, o)
+		switch {
+		case arg.rest:
+			usage = append(usage, "["+name+"...]")
+		case getStructFieldTag(arg.field, valueTag) != "" || isPointerStructField(arg.field, o):
+			usage = append(usage, "["+name+"]")
+		default:
+			usage = append(usage, "<"+name+">")
+		}
+	}
+
+	return strings.Join(usage, " "), nil
+}
+
+// argsToStruct folds positional arguments from context into
+// the struct fields with arg tag. It returns ErrMissingArgs if some
+// of the arguments without default values (except pointers) are missing and
+// ErrExtraArgs if there are more arguments than fields.
+// Structs without arg fields accept any arguments.
+// Default values are not used if only set values should be folded.
+func argsToStruct(context *cli.Context, v interface{}, o *options) error {
+	var (
+		args    = argsFromContext(context)
+		missing []string
+		value   reflect.Value
+		used    int
+	)
+
+	fields, err := argFieldsFromStruct(v, o)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	for _, arg := range fields {
+		var (
+			name         = argNameFromStructField(arg.field, o)
+			defaultValue = getStructFieldTag(arg.field, valueTag)
+		)
+
+		switch {
+		case arg.rest && arg.index < len(args):
+			value = reflect.MakeSlice(arg.field.Type, 0, len(args)-arg.index)
+			for _, s := range args[arg.index:] {
+				elem, err := o.types.valueFromString(arg.field, arg.field.Type.Elem(), s)
+				if err != nil {
+					return NewErrParse(name, s, err)
+				}
+				value = reflect.Append(value, elem)
+			}
+			used = len(args)
+		case arg.rest && defaultValue == "":
+			continue
+		case arg.index < len(args):
+			value, err = o.types.valueFromString(arg.field, arg.field.Type, args[arg.index])
+			if err != nil {
+				return NewErrParse(name, args[arg.index], err)
+			}
+			used = arg.index + 1
+		case defaultValue != "" && o.onlySet:
+			continue
+		case defaultValue == "" && isPointerStructField(arg.field, o):
+			// Pointer is left nil if argument is missing.
+			continue
+		case defaultValue != "":
+			value, err = o.types.valueFromString(arg.field, arg.field.Type, defaultValue)
+			if err != nil {
+				return NewErrParse(name, defaultValue, err)
+			}
+		default:
+			missing = append(missing, name)
+			continue
+		}
+
+		err = setStructFieldValue(arg.field, arg.value, value.Interface(), o)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(missing) > 0 {
+		return NewErrMissingArgs(missing)
+	}
+	if used < len(args) {
+		return NewErrExtraArgs(args[used:])
+	}
+
+	return nil
+}
+
+// argsFromContext returns positional arguments from context,
+// arguments terminator is skipped. Terminator which comes before
+// any positional argument is consumed by the flags parser, it is
+// the case when the first argument looks like a flag (or is `--`
+// itself), so no other `--` is skipped then.
+// Raw arguments are not available in the context, so `--` which
+// comes before the argument which does not look like a flag
+// could not be told apart and the next `--` is skipped.
+func argsFromContext(context *cli.Context) []string {
+	var (
+		args = context.Args()
+	)
+
+	if len(args) == 0 || isFlagLikeArg(args[0]) {
+		return args
+	}
+
+	for n, arg := range args {
+		if arg == argsTerminator {
+			return append(append([]string{}, args[:n]...), args[n+1:]...)
+		}
+	}
+
+	return args
+}
+
+// isFlagLikeArg returns true if arg would be parsed as a flag
+// (or a terminator) by the flags parser, single `-` is an argument.
+func isFlagLikeArg(arg string) bool {
+	return len(arg) > 1 && strings.HasPrefix(arg, "-")
+}
+
+// argFieldsFromStruct returns the fields of the struct in v which has
+// arg tag sorted by the argument index, the rest field is the last.
+func argFieldsFromStruct(v interface{}, o *options) ([]argField, error) {
+	var (
+		reflectType  = indirectType(reflect.TypeOf(v))
+		reflectValue = indirectValue(reflect.ValueOf(v))
+		fields       []argField
+		field        reflect.StructField
+		tag          string
+		index        int
+		err          error
+	)
+
+	if !reflectValue.IsValid() {
+		return nil, NewErrInvalid(v)
+	}
+
+	err = shouldBeStruct(reflectValue)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fields of the nested structs with arg tag are reported by walkStruct.
+	err = walkStruct(
+		v,
+		"",
+		o,
+		func(field reflect.StructField, value reflect.Value, prefix string) error { return nil },
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field = range structFields(reflectType, o) {
+		if !isStructFieldExported(field) || !isArgStructField(field) {
+			continue
+		}
+
+		tag = getStructFieldTag(field, argTag)
+		if tag == argRest {
+			err = shouldBeSlice(reflectValue.FieldByIndex(field.Index))
+			if err != nil {
+				return nil, err
+			}
+
+			fields = append(
+				fields,
+				argField{field: field, value: reflectValue.FieldByIndex(field.Index), rest: true},
+			)
+			continue
+		}
+
+		index, err = strconv.Atoi(tag)
+		if err != nil || index < 0 {
+			return nil, NewErrInvalidArgIndex(field.Name, tag)
+		}
+
+		fields = append(
+			fields,
+			argField{field: field, value: reflectValue.FieldByIndex(field.Index), index: index},
+		)
+	}
+
+	sort.SliceStable(
+		fields,
+		func(i, j int) bool {
+			if fields[i].rest != fields[j].rest {
+				return fields[j].rest
+			}
+			return fields[i].index < fields[j].index
+		},
+	)
+
+	for n, arg := range fields {
+		switch {
+		case arg.rest && n != len(fields)-1:
+			return nil, NewErrInvalidArgIndex(arg.field.Name, argRest)
+		case arg.rest:
+			arg.index = n
+			fields[n] = arg
+		case arg.index != n:
+			return nil, NewErrInvalidArgIndex(arg.field.Name, strconv.Itoa(arg.index))
+		}
+	}
+
+	return fields, nil
+}
+
+func argNameFromStructField(field reflect.StructField, o *options) string {
+	return flagNameFromStructField(field, "", o)
+}
+
+// isArgStructField reports whether field should be
+// bound to the positional argument.
+func isArgStructField(field reflect.StructField) bool {
+	return getStructFieldTag(field, argTag) != ""
+}
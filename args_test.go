@@ -0,0 +1,202 @@
+package clistruct
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testArgs struct {
+	Verbose bool
+	Source  string          `arg:"0"`
+	Port    int             `arg:"1" name:"port"`
+	Timeout time.Duration   `arg:"2" value:"1s"`
+	Rest    []time.Duration `arg:"rest" name:"delays"`
+}
+
+func TestArgsUsageFromStruct(t *testing.T) {
+	usage, err := ArgsUsageFromStruct(&testArgs{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, "<source> <port> [timeout] [delays...]", usage)
+}
+
+func TestArgsToStruct(t *testing.T) {
+	sample := &testArgs{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags, "--verbose", "src", "80", "--", "5s", "1m", "-1s")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(
+		t,
+		&testArgs{
+			Verbose: true,
+			Source:  "src",
+			Port:    80,
+			Timeout: 5 * time.Second,
+			Rest:    []time.Duration{time.Minute, -time.Second},
+		},
+		sample,
+	)
+
+	sample = &testArgs{}
+
+	context, err = runApp(flags, "src", "80")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(
+		t,
+		&testArgs{
+			Source:  "src",
+			Port:    80,
+			Timeout: time.Second,
+		},
+		sample,
+	)
+}
+
+func TestArgsToStructMissingAndExtra(t *testing.T) {
+	type Sample struct {
+		Source string `arg:"0"`
+		Target string `arg:"1"`
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags, "src")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	switch v := err.(type) {
+	case *ErrMissingArgs:
+		assert.EqualValues(t, []string{"target"}, v.Names())
+	default:
+		t.Error(err)
+	}
+
+	context, err = runApp(flags, "src", "dst", "extra")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	switch err.(type) {
+	case *ErrExtraArgs:
+	default:
+		t.Error(err)
+	}
+}
+
+func TestArgsToStructTerminator(t *testing.T) {
+	type Sample struct {
+		Source string   `arg:"0"`
+		Target string   `arg:"1"`
+		Rest   []string `arg:"rest"`
+	}
+
+	for _, c := range []struct {
+		args   []string
+		sample Sample
+	}{
+		{[]string{"src", "--", "-x"}, Sample{Source: "src", Target: "-x"}},
+		{[]string{"--", "-x", "--", "3"}, Sample{Source: "-x", Target: "--", Rest: []string{"3"}}},
+		{[]string{"--", "--", "-x"}, Sample{Source: "--", Target: "-x"}},
+	} {
+		sample := &Sample{}
+
+		flags, err := FlagsFromStruct(sample)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		context, err := runApp(flags, c.args...)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = FlagsToStruct(context, sample)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.EqualValues(t, &c.sample, sample, "%v", c.args)
+	}
+}
+
+func TestArgsFromStructInvalidIndex(t *testing.T) {
+	sample := struct {
+		Source string `arg:"0"`
+		Target string `arg:"2"`
+	}{}
+
+	_, err := ArgsUsageFromStruct(&sample)
+	switch err.(type) {
+	case *ErrInvalidArgIndex:
+	default:
+		t.Error(err)
+	}
+
+	type Pair struct {
+		Source string `arg:"0"`
+	}
+
+	// Arguments are bound only to the fields of the top level struct.
+	for _, sample := range []interface{}{
+		&struct{ Nested Pair }{},
+		&struct {
+			Pair `embed:"prefix"`
+		}{},
+	} {
+		_, err = FlagsFromStruct(sample, WithStrict())
+		assert.IsType(t, &ErrInvalidArgIndex{}, err)
+
+		_, err = ArgsUsageFromStruct(sample)
+		assert.IsType(t, &ErrInvalidArgIndex{}, err)
+	}
+
+	// Fields of the embedded structs are promoted.
+	usage, err := ArgsUsageFromStruct(&struct{ Pair }{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, "<source>", usage)
+}
@@ -0,0 +1,178 @@
+package clistruct
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// Runner is implemented by the command structs
+// which Run method should be used as the command action.
+type Runner interface {
+	Run(*cli.Context) error
+}
+
+// Beforer is implemented by the command structs
+// which Before method should be run before the command action.
+type Beforer interface {
+	Before(*cli.Context) error
+}
+
+// Afterer is implemented by the command structs
+// which After method should be run after the command action.
+type Afterer interface {
+	After(*cli.Context) error
+}
+
+// CommandsFromStruct generates cli.Command slice for github.com/urfave/cli
+// from the struct fields tagged with cmd tag, for example `cmd:"serve"`.
+// Command flags are generated from the field struct, command usage,
+// aliases and visibility are taken from usage, aliases and hidden tags.
+// Struct fields of the command tagged with cmd tag become subcommands.
+// Parsed flags are folded into the field struct before command action runs,
+// with WithConfig option each command gets its own config flag.
+// Command struct implementing Runner, Beforer or Afterer
+// has these methods bound as the command Action, Before and After.
+func CommandsFromStruct(v interface{}, opts ...Option) ([]cli.Command, error) {
+	return defaultMapper.CommandsFromStruct(v, opts...)
+}
+
+// CommandsFromStruct is like CommandsFromStruct function, but uses
+// the mapper options, opts are applied on top of them.
+func (m *Mapper) CommandsFromStruct(v interface{}, opts ...Option) ([]cli.Command, error) {
+	err := checkValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return commandsFromStruct(v, m.options.with(opts))
+}
+
+func commandsFromStruct(v interface{}, o *options) ([]cli.Command, error) {
+	var (
+		reflectType  = indirectType(reflect.TypeOf(v))
+		reflectValue = indirectValue(reflect.ValueOf(v))
+		command      cli.Command
+		commands     []cli.Command
+		field        reflect.StructField
+		err          error
+	)
+
+	if !reflectValue.IsValid() {
+		return nil, NewErrInvalid(v)
+	}
+
+	err = shouldBeStruct(reflectValue)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field = range structFields(reflectType, o) {
+		if !isStructFieldExported(field) || !isCommandStructField(field) {
+			continue
+		}
+
+		err = shouldBeStruct(reflectValue.FieldByIndex(field.Index))
+		if err != nil {
+			return nil, err
+		}
+
+		command, err = commandFromStructField(
+			field,
+			reflectValue.FieldByIndex(field.Index).Addr().Interface(),
+			o,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		commands = append(commands, command)
+	}
+
+	return commands, nil
+}
+
+func commandFromStructField(field reflect.StructField, v interface{}, o *options) (cli.Command, error) {
+	var (
+		name    = getStructFieldTag(field, cmdTag)
+		command cli.Command
+		err     error
+	)
+
+	o = o.forCommand(name)
+
+	command = cli.Command{
+		Name:    name,
+		Aliases: commandAliasesFromStructField(field),
+		Usage:   getStructFieldTag(field, usageTag),
+		Hidden:  isStructFieldTagTrue(field, hiddenTag),
+	}
+
+	command.Flags, err = flagsFromStruct(v, o)
+	if err != nil {
+		return cli.Command{}, err
+	}
+
+	command.Subcommands, err = commandsFromStruct(v, o)
+	if err != nil {
+		return cli.Command{}, err
+	}
+
+	command.ArgsUsage, err = argsUsageFromStruct(v, o)
+	if err != nil {
+		return cli.Command{}, err
+	}
+
+	command.Before = func(context *cli.Context) error {
+		if context.Command.Name == "" {
+			// Leaf commands get their context.Command only after
+			// Before is run, but it is required to check which
+			// flags was set with environment variables.
+			context.Command = command
+		}
+
+		err := flagsToStruct(context, v, o)
+		if err != nil {
+			return err
+		}
+
+		if beforer, ok := v.(Beforer); ok {
+			return beforer.Before(context)
+		}
+
+		return nil
+	}
+
+	if runner, ok := v.(Runner); ok {
+		command.Action = runner.Run
+	}
+	if afterer, ok := v.(Afterer); ok {
+		command.After = afterer.After
+	}
+
+	return command, nil
+}
+
+func commandAliasesFromStructField(field reflect.StructField) []string {
+	var (
+		aliases []string
+	)
+
+	for _, alias := range strings.Split(getStructFieldTag(field, aliasesTag), nameDelimiter) {
+		alias = strings.TrimSpace(alias)
+		if alias == "" {
+			continue
+		}
+
+		aliases = append(aliases, alias)
+	}
+
+	return aliases
+}
+
+// isCommandStructField reports whether field
+// should be mapped to the command.
+func isCommandStructField(field reflect.StructField) bool {
+	return getStructFieldTag(field, cmdTag) != ""
+}
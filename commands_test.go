@@ -0,0 +1,181 @@
+package clistruct
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+type testCommands struct {
+	Debug bool
+	Serve struct {
+		Host string `value:"localhost"`
+		Port int    `value:"80"`
+	} `cmd:"serve" usage:"Serve requests" aliases:"s,srv"`
+	DB struct {
+		DSN     string `required:"true"`
+		Migrate struct {
+			Steps int
+		} `cmd:"migrate" usage:"Migrate database"`
+	} `cmd:"db" hidden:"true"`
+}
+
+func runCommands(v interface{}, opts []Option, args ...string) error {
+	var (
+		app = cli.NewApp()
+		err error
+	)
+
+	app.Writer = ioutil.Discard
+	app.Flags, err = FlagsFromStruct(v, opts...)
+	if err != nil {
+		return err
+	}
+	app.Commands, err = CommandsFromStruct(v, opts...)
+	if err != nil {
+		return err
+	}
+	app.Before = func(context *cli.Context) error {
+		return FlagsToStruct(context, v)
+	}
+
+	// XXX: First argument is a program name, so it is empty.
+	return app.Run(append([]string{""}, args...))
+}
+
+func TestCommandsFromStruct(t *testing.T) {
+	sample := &testCommands{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.EqualValues(t, []cli.Flag{cli.BoolFlag{Name: "debug"}}, flags)
+
+	commands, err := CommandsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.Len(t, commands, 2)
+
+	assert.EqualValues(t, "serve", commands[0].Name)
+	assert.EqualValues(t, "Serve requests", commands[0].Usage)
+	assert.EqualValues(t, []string{"s", "srv"}, commands[0].Aliases)
+	assert.EqualValues(
+		t,
+		[]cli.Flag{
+			cli.StringFlag{Name: "host", Value: "localhost"},
+			cli.IntFlag{Name: "port", Value: 80},
+		},
+		commands[0].Flags,
+	)
+
+	assert.EqualValues(t, "db", commands[1].Name)
+	assert.True(t, commands[1].Hidden)
+	assert.EqualValues(t, []cli.Flag{cli.StringFlag{Name: "dsn"}}, commands[1].Flags)
+	assert.Len(t, commands[1].Subcommands, 1)
+	assert.EqualValues(t, "migrate", commands[1].Subcommands[0].Name)
+	assert.EqualValues(t, []cli.Flag{cli.IntFlag{Name: "steps"}}, commands[1].Subcommands[0].Flags)
+}
+
+func TestCommandsToStruct(t *testing.T) {
+	sample := &testCommands{}
+
+	err := runCommands(sample, nil, "--debug", "srv", "--port", "8080")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expectedSample := &testCommands{Debug: true}
+	expectedSample.Serve.Host = "localhost"
+	expectedSample.Serve.Port = 8080
+
+	assert.EqualValues(t, expectedSample, sample)
+
+	sample = &testCommands{}
+
+	err = runCommands(sample, nil, "db", "--dsn", "postgres://", "migrate", "--steps", "2")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expectedSample = &testCommands{}
+	expectedSample.DB.DSN = "postgres://"
+	expectedSample.DB.Migrate.Steps = 2
+
+	assert.EqualValues(t, expectedSample, sample)
+}
+
+func TestCommandsToStructEnv(t *testing.T) {
+	os.Setenv("CLISTRUCT_TEST_SERVE_PORT", "8080")
+	defer os.Unsetenv("CLISTRUCT_TEST_SERVE_PORT")
+
+	type Sample struct {
+		Serve struct {
+			Port int `required:"true"`
+		} `cmd:"serve"`
+	}
+
+	sample := &Sample{}
+
+	err := runCommands(
+		sample,
+		[]Option{WithEnvPrefix("CLISTRUCT_TEST_")},
+		"serve",
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, 8080, sample.Serve.Port)
+}
+
+type testServeCommand struct {
+	Port int `value:"80"`
+
+	calls []string
+}
+
+func (c *testServeCommand) Before(context *cli.Context) error {
+	c.calls = append(c.calls, "before")
+	return nil
+}
+
+func (c *testServeCommand) Run(context *cli.Context) error {
+	c.calls = append(c.calls, "run "+strconv.Itoa(c.Port))
+	return nil
+}
+
+func (c *testServeCommand) After(context *cli.Context) error {
+	c.calls = append(c.calls, "after")
+	return nil
+}
+
+func TestCommandsFromStructRunner(t *testing.T) {
+	type Sample struct {
+		Serve testServeCommand `cmd:"serve"`
+	}
+
+	sample := &Sample{}
+
+	err := runCommands(sample, nil, "serve", "--port", "8080")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(
+		t,
+		[]string{"before", "run 8080", "after"},
+		sample.Serve.calls,
+	)
+}
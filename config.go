@@ -0,0 +1,414 @@
+package clistruct
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configFlagName  = "config"
+	configFlagUsage = "Load configuration from `FILE`"
+
+	yamlMergeTag = "!!merge"
+	yamlNullTag  = "!!null"
+)
+
+// LoadConfig reads the config file at path into the struct fields in v.
+// Keys are the flag names, config tag could be used to override
+// the key (it is prefixed just like the name tag), "-" excludes the field.
+// Path segments of the key could be nested, so `db.port` key
+// could be written as `{"db": {"port": 5432}}`.
+// Format is chosen by the file extension: .json, .yaml, .yml or .toml.
+func LoadConfig(path string, v interface{}, opts ...Option) error {
+	return defaultMapper.LoadConfig(path, v, opts...)
+}
+
+// LoadConfig is like LoadConfig function, but uses
+// the mapper options, opts are applied on top of them.
+func (m *Mapper) LoadConfig(path string, v interface{}, opts ...Option) error {
+	err := checkValue(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = configToStruct(path, v, m.options.with(opts))
+	return err
+}
+
+// configToStruct loads the config file at path into v.
+// It returns the primary names of the flags
+// which fields were loaded from the config.
+func configToStruct(path string, v interface{}, o *options) (map[string]bool, error) {
+	var (
+		loaded = map[string]bool{}
+	)
+
+	config, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	err = walkStruct(
+		v,
+		"",
+		o,
+		func(field reflect.StructField, value reflect.Value, prefix string) error {
+			key := configKeyFromStructField(field, prefix, o)
+			if key == "" {
+				return nil
+			}
+
+			configValue, ok := lookupConfigValue(config, key)
+			if !ok {
+				return nil
+			}
+
+			parsed, err := valueFromConfig(field, field.Type, configValue, o)
+			if err != nil {
+				return NewErrParse(key, configValueString(field, configValue), err)
+			}
+
+			err = setStructFieldValue(field, value, parsed.Interface(), o)
+			if err != nil {
+				return err
+			}
+
+			loaded[flagNameFromStructField(field, prefix, o)] = true
+			return nil
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return loaded, nil
+}
+
+// configFromContext loads the config file which path
+// is the value of the config flag into v.
+// Missing file is not an error unless the path was set explicitly.
+func configFromContext(context *cli.Context, v interface{}, o *options) (map[string]bool, error) {
+	if !o.config {
+		return nil, nil
+	}
+
+	path := context.String(configFlagName)
+	if path == "" {
+		return nil, nil
+	}
+	if !context.IsSet(configFlagName) {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, nil
+		}
+	}
+
+	return configToStruct(path, v, o)
+}
+
+// configFlag returns the flag for the config file path.
+func configFlag(o *options) cli.Flag {
+	flag := cli.StringFlag{
+		Name:  configFlagName,
+		Usage: configFlagUsage,
+		Value: o.configPath,
+	}
+	if o.envAuto {
+		flag.EnvVar = envNameFromFlagName(o.envPrefix, configFlagName)
+	}
+
+	return flag
+}
+
+// readConfig reads and decodes the config file at path.
+func readConfig(path string) (map[string]interface{}, error) {
+	var (
+		config interface{}
+	)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, NewErrConfig(path, err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&config)
+	case ".yaml", ".yml":
+		node := &yaml.Node{}
+		err = yaml.Unmarshal(data, node)
+		if err == nil {
+			config = valueFromYAMLNode(node)
+		}
+	case ".toml":
+		table := map[string]interface{}{}
+		_, err = toml.Decode(string(data), &table)
+		config = table
+	default:
+		return nil, NewErrUnknownConfigFormat(path)
+	}
+	if err != nil {
+		return nil, NewErrConfig(path, err)
+	}
+
+	if config == nil {
+		// Empty file has no values.
+		return map[string]interface{}{}, nil
+	}
+
+	table, ok := normalizeConfigValue(config).(map[string]interface{})
+	if !ok {
+		return nil, NewErrConfig(
+			path,
+			NewErrTypeMistmatch("map", fmt.Sprintf("%T", config)),
+		)
+	}
+
+	return table, nil
+}
+
+// normalizeConfigValue converts maps decoded from the
+// config into map[string]interface{} and lists into []interface{},
+// so values from all formats could be handled the same way.
+func normalizeConfigValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		table := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			table[fmt.Sprint(k)] = normalizeConfigValue(v)
+		}
+		return table
+	case map[string]interface{}:
+		table := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			table[k] = normalizeConfigValue(v)
+		}
+		return table
+	case []interface{}:
+		list := make([]interface{}, len(value))
+		for n, v := range value {
+			list[n] = normalizeConfigValue(v)
+		}
+		return list
+	case []map[string]interface{}:
+		list := make([]interface{}, len(value))
+		for n, v := range value {
+			list[n] = normalizeConfigValue(v)
+		}
+		return list
+	default:
+		return v
+	}
+}
+
+// valueFromYAMLNode converts the YAML node into maps and lists
+// like the ones decoded from other formats, but scalars are kept
+// as nodes, so they are parsed from the text as it is written
+// (YAML resolves `no`, `on` or `1.10` to the values which could not
+// be formatted back to the same text).
+func valueFromYAMLNode(node *yaml.Node) interface{} {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil
+		}
+		return valueFromYAMLNode(node.Content[0])
+	case yaml.AliasNode:
+		return valueFromYAMLNode(node.Alias)
+	case yaml.SequenceNode:
+		list := make([]interface{}, len(node.Content))
+		for n, item := range node.Content {
+			list[n] = valueFromYAMLNode(item)
+		}
+		return list
+	case yaml.MappingNode:
+		var (
+			table  = map[string]interface{}{}
+			merged []map[string]interface{}
+		)
+
+		for n := 0; n+1 < len(node.Content); n += 2 {
+			key, value := node.Content[n], node.Content[n+1]
+			if key.ShortTag() != yamlMergeTag {
+				table[key.Value] = valueFromYAMLNode(value)
+				continue
+			}
+
+			switch v := valueFromYAMLNode(value).(type) {
+			case map[string]interface{}:
+				merged = append(merged, v)
+			case []interface{}:
+				for _, item := range v {
+					if m, ok := item.(map[string]interface{}); ok {
+						merged = append(merged, m)
+					}
+				}
+			}
+		}
+
+		// Keys of the mapping win over the merged keys,
+		// first merged mapping wins over the next ones.
+		for _, m := range merged {
+			for k, v := range m {
+				if _, ok := table[k]; !ok {
+					table[k] = v
+				}
+			}
+		}
+
+		return table
+	case yaml.ScalarNode:
+		if node.ShortTag() == yamlNullTag {
+			return nil
+		}
+		return node
+	default:
+		return nil
+	}
+}
+
+// valueFromYAMLScalar parses the YAML scalar into the value of type t
+// from the text as it is written, boolean fields accept YAML 1.1
+// booleans (like `yes` or `off`) too.
+func valueFromYAMLScalar(field reflect.StructField, t reflect.Type, node *yaml.Node, o *options) (reflect.Value, error) {
+	var (
+		elem = t
+		text = node.Value
+	)
+
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() == reflect.Bool {
+		var value bool
+		if node.Decode(&value) == nil {
+			text = strconv.FormatBool(value)
+		}
+	}
+
+	return o.types.valueFromString(field, t, text)
+}
+
+// lookupConfigValue returns the value of the key from config,
+// key could be a path to the value in nested maps.
+func lookupConfigValue(config map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := config[key]; ok {
+		return value, true
+	}
+
+	n := strings.Index(key, pathDelimiter)
+	if n < 0 {
+		return nil, false
+	}
+
+	nested, ok := config[key[:n]].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	return lookupConfigValue(nested, key[n+len(pathDelimiter):])
+}
+
+// valueFromConfig converts the value decoded from the config
+// into the value of type t, scalars are parsed just like flag values.
+func valueFromConfig(field reflect.StructField, t reflect.Type, v interface{}, o *options) (reflect.Value, error) {
+	switch value := v.(type) {
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			return reflect.Value{}, NewErrTypeMistmatch(t.String(), "list")
+		}
+
+		slice := reflect.MakeSlice(t, 0, len(value))
+		for _, v := range value {
+			elem, err := valueFromConfig(field, t.Elem(), v, o)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+
+		return slice, nil
+	case map[string]interface{}:
+		if t.Kind() != reflect.Map {
+			return reflect.Value{}, NewErrTypeMistmatch(t.String(), "map")
+		}
+
+		table := reflect.MakeMap(t)
+		for k, v := range value {
+			key, err := o.types.valueFromString(field, t.Key(), k)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			elem, err := valueFromConfig(field, t.Elem(), v, o)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			table.SetMapIndex(key, elem)
+		}
+
+		return table, nil
+	case *yaml.Node:
+		return valueFromYAMLScalar(field, t, value, o)
+	default:
+		return o.types.valueFromString(field, t, configValueString(field, v))
+	}
+}
+
+// configValueString formats value decoded from the config,
+// lists and maps are formatted like fmt.Sprint does.
+func configValueString(field reflect.StructField, v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case *yaml.Node:
+		return value.Value
+	case []interface{}:
+		items := make([]string, len(value))
+		for n, v := range value {
+			items[n] = configValueString(field, v)
+		}
+		return "[" + strings.Join(items, " ") + "]"
+	case map[string]interface{}:
+		items := make([]string, 0, len(value))
+		for k, v := range value {
+			items = append(items, k+":"+configValueString(field, v))
+		}
+		sort.Strings(items)
+		return "map[" + strings.Join(items, " ") + "]"
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case time.Time:
+		return value.Format(timeLayoutFromStructField(field))
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// configKeyFromStructField returns the key of the field value
+// in the config, empty key means the field should not be loaded.
+func configKeyFromStructField(field reflect.StructField, prefix string, o *options) string {
+	key := getStructFieldTag(field, configTag)
+
+	switch key {
+	case skipTagValue:
+		return ""
+	case "":
+		return flagNameFromStructField(field, prefix, o)
+	default:
+		return joinFlagPath(prefix, key)
+	}
+}
@@ -0,0 +1,313 @@
+package clistruct
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Host    string `value:"localhost" env:"CLISTRUCT_TEST_CONFIG_HOST"`
+	Port    int    `value:"80"`
+	Debug   bool
+	Token   string `required:"true"`
+	Secret  string `config:"-"`
+	Timeout time.Duration
+	Tags    []string
+	Labels  map[string]int
+	DB      struct {
+		Name string `config:"database"`
+		Port uint16 `value:"5432"`
+	}
+}
+
+func writeConfig(t *testing.T, name string, data string) string {
+	dir, err := ioutil.TempDir("", "clistruct")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	err = ioutil.WriteFile(path, []byte(data), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	expected := &testConfig{
+		Host:    "example.com",
+		Port:    8080,
+		Debug:   true,
+		Token:   "token",
+		Timeout: 5 * time.Second,
+		Tags:    []string{"a", "b"},
+		Labels:  map[string]int{"x": 1},
+	}
+	expected.DB.Name = "app"
+	expected.DB.Port = 6432
+
+	for name, data := range map[string]string{
+		"config.json": `{
+			"host": "example.com", "port": 8080, "debug": true,
+			"token": "token", "secret": "secret", "timeout": "5s",
+			"tags": ["a", "b"], "labels": {"x": 1},
+			"db": {"database": "app", "port": 6432}
+		}`,
+		"config.yaml": `
+host: example.com
+port: 8080
+debug: true
+token: token
+secret: secret
+timeout: 5s
+tags: [a, b]
+labels:
+  x: 1
+db.database: app
+db:
+  port: 6432
+`,
+		"config.toml": `
+host = "example.com"
+port = 8080
+debug = true
+token = "token"
+secret = "secret"
+timeout = "5s"
+tags = ["a", "b"]
+labels = { x = 1 }
+
+[db]
+database = "app"
+port = 6432
+`,
+	} {
+		path := writeConfig(t, name, data)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		sample := &testConfig{}
+		err := LoadConfig(path, sample)
+		if err != nil {
+			t.Error(name, err)
+			continue
+		}
+
+		assert.EqualValues(t, expected, sample, name)
+	}
+}
+
+func TestLoadConfigYAMLScalars(t *testing.T) {
+	type Sample struct {
+		Country string
+		Tags    []string
+		Version string
+		Mode    *string
+		Debug   bool
+		Verbose *bool
+		Labels  map[string]string
+		Base    struct {
+			Host string
+			Port int
+		}
+		DB struct {
+			Host string
+			Port int
+		}
+	}
+
+	path := writeConfig(t, "config.yaml", `
+country: NO
+tags: [x, y, on]
+version: 1.10
+mode: off
+debug: yes
+verbose: off
+labels: {enabled: true, region: no}
+base: &base
+  host: localhost
+  port: 5432
+db:
+  <<: *base
+  port: 6432
+`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	sample := &Sample{}
+	err := LoadConfig(path, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Strings are taken as they are written,
+	// booleans accept YAML 1.1 values.
+	assert.EqualValues(t, "NO", sample.Country)
+	assert.EqualValues(t, []string{"x", "y", "on"}, sample.Tags)
+	assert.EqualValues(t, "1.10", sample.Version)
+	assert.EqualValues(t, "off", *sample.Mode)
+	assert.EqualValues(t, true, sample.Debug)
+	assert.EqualValues(t, false, *sample.Verbose)
+	assert.EqualValues(t, map[string]string{"enabled": "true", "region": "no"}, sample.Labels)
+	assert.EqualValues(t, "localhost", sample.DB.Host)
+	assert.EqualValues(t, 6432, sample.DB.Port)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	path := writeConfig(t, "config.ini", "host = example.com")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	assert.IsType(t, &ErrUnknownConfigFormat{}, LoadConfig(path, &testConfig{}))
+
+	path = writeConfig(t, "config.json", `{"port": "http"}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	assert.IsType(t, &ErrParse{}, LoadConfig(path, &testConfig{}))
+
+	path = writeConfig(t, "config.json", `["port"]`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	assert.IsType(t, &ErrConfig{}, LoadConfig(path, &testConfig{}))
+	assert.IsType(t, &ErrConfig{}, LoadConfig(path+".missing", &testConfig{}))
+}
+
+func TestFlagsToStructConfig(t *testing.T) {
+	path := writeConfig(
+		t,
+		"config.yaml",
+		"host: config.example.com\nport: 8080\ntoken: token\ndb: {port: 6432}\n",
+	)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	sample := &testConfig{}
+
+	flags, err := FlagsFromStruct(sample, WithConfig(""))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	os.Setenv("CLISTRUCT_TEST_CONFIG_HOST", "env.example.com")
+	defer os.Unsetenv("CLISTRUCT_TEST_CONFIG_HOST")
+
+	context, err := runApp(flags, "--config", path, "--db.port", "7432")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample, WithConfig(""))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// default < config < env < flag
+	assert.EqualValues(t, "env.example.com", sample.Host)
+	assert.EqualValues(t, 8080, sample.Port)
+	assert.EqualValues(t, "token", sample.Token)
+	assert.EqualValues(t, 7432, sample.DB.Port)
+
+	context, err = runApp(flags, "--config", path+".missing")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, &testConfig{}, WithConfig(""))
+	assert.IsType(t, &ErrConfig{}, err)
+}
+
+func TestFlagsToStructConfigBound(t *testing.T) {
+	path := writeConfig(
+		t,
+		"config.json",
+		`{"level": "error", "verbose": "error", "gen": "fromfile", "port": 8080}`,
+	)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	type Sample struct {
+		Level   testLevel
+		Verbose testLevel `value:"info"`
+		Gen     testGeneric
+		Port    int
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample, WithConfig(""))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(
+		flags,
+		"--config", path,
+		"--level", "info",
+		"--gen", "fromflag",
+		"--port", "80",
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample, WithConfig(""))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Bound flags which were set override the config
+	// just like the other flags, defaults do not.
+	assert.EqualValues(
+		t,
+		&Sample{
+			Level:   1,
+			Verbose: 2,
+			Gen:     testGeneric{"fromflag"},
+			Port:    80,
+		},
+		sample,
+	)
+}
+
+func TestFlagsToStructConfigDefaultPath(t *testing.T) {
+	path := writeConfig(t, "config.json", `{"token": "token"}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	for _, c := range []struct {
+		path  string
+		token string
+		err   error
+	}{
+		{path, "token", nil},
+		{path + ".missing", "", NewErrRequired([]string{"token"})},
+	} {
+		sample := &testConfig{}
+
+		flags, err := FlagsFromStruct(sample, WithConfig(c.path))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		context, err := runApp(flags)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = FlagsToStruct(context, sample, WithConfig(c.path))
+		assert.EqualValues(t, c.err, err)
+		assert.EqualValues(t, c.token, sample.Token)
+		assert.EqualValues(t, "localhost", sample.Host)
+	}
+}
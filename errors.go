@@ -3,6 +3,7 @@ package clistruct
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 // ErrInvalid is an error indicating that invalid values was passed.
@@ -104,3 +105,413 @@ func (e *ErrFlagTypeCanNotHaveValue) Error() string {
 func NewErrFlagTypeCanNotHaveValue(t string) error {
 	return &ErrFlagTypeCanNotHaveValue{t}
 }
+
+//
+
+// ErrDuplicateFlagName is an error indicating that
+// flag name or alias is used by more than one field.
+type ErrDuplicateFlagName struct {
+	name string
+}
+
+func (e *ErrDuplicateFlagName) Error() string {
+	return fmt.Sprintf(
+		"Flag name '%s' is used more than once",
+		e.name,
+	)
+}
+
+// NewErrDuplicateFlagName creates new ErrDuplicateFlagName.
+func NewErrDuplicateFlagName(name string) error {
+	return &ErrDuplicateFlagName{name}
+}
+
+//
+
+// ErrRequired is an error indicating that flags
+// marked as required was not set.
+type ErrRequired struct {
+	names []string
+}
+
+func (e *ErrRequired) Error() string {
+	return fmt.Sprintf(
+		"Required flags was not set: '%s'",
+		strings.Join(e.names, "', '"),
+	)
+}
+
+// Names returns the names of the flags which was not set.
+func (e *ErrRequired) Names() []string {
+	return e.names
+}
+
+// NewErrRequired creates new ErrRequired.
+func NewErrRequired(names []string) error {
+	return &ErrRequired{names}
+}
+
+//
+
+// ErrOverflow is an error indicating that value
+// could not be represented by the field type.
+type ErrOverflow struct {
+	field string
+	value string
+	t     string
+}
+
+func (e *ErrOverflow) Error() string {
+	return fmt.Sprintf(
+		"Value '%s' of field '%s' overflows type '%s'",
+		e.value,
+		e.field,
+		e.t,
+	)
+}
+
+// NewErrOverflow creates new ErrOverflow.
+func NewErrOverflow(field string, value string, t string) error {
+	return &ErrOverflow{field, value, t}
+}
+
+//
+
+// ErrParse is an error indicating that value
+// for the flag could not be parsed.
+type ErrParse struct {
+	name  string
+	value string
+	err   error
+}
+
+func (e *ErrParse) Error() string {
+	return fmt.Sprintf(
+		"Failed to parse value '%s' for flag '%s': %s",
+		e.value,
+		e.name,
+		e.err,
+	)
+}
+
+// NewErrParse creates new ErrParse.
+func NewErrParse(name string, value string, err error) error {
+	return &ErrParse{name, value, err}
+}
+
+//
+
+// ErrInvalidPair is an error indicating that
+// map value is not a key=value pair.
+type ErrInvalidPair struct {
+	pair string
+}
+
+func (e *ErrInvalidPair) Error() string {
+	return fmt.Sprintf(
+		"Expected 'key=value' pair, got '%s'",
+		e.pair,
+	)
+}
+
+// NewErrInvalidPair creates new ErrInvalidPair.
+func NewErrInvalidPair(pair string) error {
+	return &ErrInvalidPair{pair}
+}
+
+//
+
+// ErrDuplicateKey is an error indicating that
+// map key was set more than once.
+type ErrDuplicateKey struct {
+	key string
+}
+
+func (e *ErrDuplicateKey) Error() string {
+	return fmt.Sprintf(
+		"Key '%s' is set more than once",
+		e.key,
+	)
+}
+
+// NewErrDuplicateKey creates new ErrDuplicateKey.
+func NewErrDuplicateKey(key string) error {
+	return &ErrDuplicateKey{key}
+}
+
+//
+
+// ErrMissingArgs is an error indicating that positional
+// arguments without default values was not passed.
+type ErrMissingArgs struct {
+	names []string
+}
+
+func (e *ErrMissingArgs) Error() string {
+	return fmt.Sprintf(
+		"Required arguments was not passed: '%s'",
+		strings.Join(e.names, "', '"),
+	)
+}
+
+// Names returns the names of the arguments which was not passed.
+func (e *ErrMissingArgs) Names() []string {
+	return e.names
+}
+
+// NewErrMissingArgs creates new ErrMissingArgs.
+func NewErrMissingArgs(names []string) error {
+	return &ErrMissingArgs{names}
+}
+
+//
+
+// ErrExtraArgs is an error indicating that there are
+// more positional arguments than fields to bind them.
+type ErrExtraArgs struct {
+	args []string
+}
+
+func (e *ErrExtraArgs) Error() string {
+	return fmt.Sprintf(
+		"Unexpected arguments: '%s'",
+		strings.Join(e.args, "', '"),
+	)
+}
+
+// NewErrExtraArgs creates new ErrExtraArgs.
+func NewErrExtraArgs(args []string) error {
+	return &ErrExtraArgs{args}
+}
+
+//
+
+// ErrInvalidArgIndex is an error indicating that arg tag
+// of the field is not a valid argument index.
+// Indexes should start from 0 and have no gaps,
+// the rest of arguments could be bound only to the last field
+// and arguments could be bound only to the fields of the top
+// level struct (not to the fields of the nested structs).
+type ErrInvalidArgIndex struct {
+	field string
+	index string
+}
+
+func (e *ErrInvalidArgIndex) Error() string {
+	return fmt.Sprintf(
+		"Field '%s' has invalid argument index '%s'",
+		e.field,
+		e.index,
+	)
+}
+
+// NewErrInvalidArgIndex creates new ErrInvalidArgIndex.
+func NewErrInvalidArgIndex(field string, index string) error {
+	return &ErrInvalidArgIndex{field, index}
+}
+
+//
+
+// ErrConstraint is an error indicating that value
+// of the flag or argument violates the constraint
+// defined with validation tag.
+type ErrConstraint struct {
+	path       string
+	name       string
+	constraint string
+	value      string
+}
+
+func (e *ErrConstraint) Error() string {
+	return fmt.Sprintf(
+		"Value '%s' of '%s' violates constraint '%s: %s'",
+		e.value,
+		e.path,
+		e.name,
+		e.constraint,
+	)
+}
+
+// Path returns the name of the flag or argument
+// which value violates the constraint.
+func (e *ErrConstraint) Path() string {
+	return e.path
+}
+
+// NewErrConstraint creates new ErrConstraint.
+func NewErrConstraint(path string, name string, constraint string, value string) error {
+	return &ErrConstraint{path, name, constraint, value}
+}
+
+//
+
+// ErrValidation is an error indicating that some of
+// the struct fields violate their constraints.
+type ErrValidation struct {
+	errors []error
+}
+
+func (e *ErrValidation) Error() string {
+	messages := make([]string, len(e.errors))
+	for n, err := range e.errors {
+		messages[n] = err.Error()
+	}
+
+	return fmt.Sprintf(
+		"Validation failed: %s",
+		strings.Join(messages, "; "),
+	)
+}
+
+// Errors returns all the validation errors.
+func (e *ErrValidation) Errors() []error {
+	return e.errors
+}
+
+// NewErrValidation creates new ErrValidation.
+func NewErrValidation(errors []error) error {
+	return &ErrValidation{errors}
+}
+
+//
+
+// ErrUnknownField is an error indicating that validation
+// tag of the field refers to the field which does not exist.
+type ErrUnknownField struct {
+	field string
+	name  string
+}
+
+func (e *ErrUnknownField) Error() string {
+	return fmt.Sprintf(
+		"Field '%s' refers to unknown field '%s'",
+		e.field,
+		e.name,
+	)
+}
+
+// NewErrUnknownField creates new ErrUnknownField.
+func NewErrUnknownField(field string, name string) error {
+	return &ErrUnknownField{field, name}
+}
+
+//
+
+// ErrStructValidation is an error indicating that Validate
+// method of the struct returned an error.
+type ErrStructValidation struct {
+	path string
+	err  error
+}
+
+func (e *ErrStructValidation) Error() string {
+	if e.path == "" {
+		return fmt.Sprintf("Validation failed: %s", e.err)
+	}
+
+	return fmt.Sprintf(
+		"Validation of '%s' failed: %s",
+		e.path,
+		e.err,
+	)
+}
+
+// Path returns the flag name prefix of the struct
+// which failed to validate, it is empty for the top level struct.
+func (e *ErrStructValidation) Path() string {
+	return e.path
+}
+
+// Err returns the error returned by Validate method.
+func (e *ErrStructValidation) Err() error {
+	return e.err
+}
+
+// NewErrStructValidation creates new ErrStructValidation.
+func NewErrStructValidation(path string, err error) error {
+	return &ErrStructValidation{path, err}
+}
+
+//
+
+// ErrConfig is an error indicating that
+// config file could not be read or decoded.
+type ErrConfig struct {
+	path string
+	err  error
+}
+
+func (e *ErrConfig) Error() string {
+	return fmt.Sprintf(
+		"Failed to load config '%s': %s",
+		e.path,
+		e.err,
+	)
+}
+
+// NewErrConfig creates new ErrConfig.
+func NewErrConfig(path string, err error) error {
+	return &ErrConfig{path, err}
+}
+
+//
+
+// ErrUnknownConfigFormat is an error indicating that
+// config file format could not be determined by its extension.
+type ErrUnknownConfigFormat struct {
+	path string
+}
+
+func (e *ErrUnknownConfigFormat) Error() string {
+	return fmt.Sprintf(
+		"Unknown format of config '%s', expected .json, .yaml, .yml or .toml",
+		e.path,
+	)
+}
+
+// NewErrUnknownConfigFormat creates new ErrUnknownConfigFormat.
+func NewErrUnknownConfigFormat(path string) error {
+	return &ErrUnknownConfigFormat{path}
+}
+
+//
+
+// ErrUnsupportedType is an error indicating that
+// field type could not be mapped to the flag.
+type ErrUnsupportedType struct {
+	field string
+	t     string
+}
+
+func (e *ErrUnsupportedType) Error() string {
+	return fmt.Sprintf(
+		"Field '%s' has type '%s' which could not be mapped to the flag",
+		e.field,
+		e.t,
+	)
+}
+
+// NewErrUnsupportedType creates new ErrUnsupportedType.
+func NewErrUnsupportedType(field string, t string) error {
+	return &ErrUnsupportedType{field, t}
+}
+
+//
+
+// ErrUnexportedField is an error indicating that
+// unexported field has tags, but it could not be mapped.
+type ErrUnexportedField struct {
+	field string
+}
+
+func (e *ErrUnexportedField) Error() string {
+	return fmt.Sprintf(
+		"Field '%s' is unexported, so it could not be mapped",
+		e.field,
+	)
+}
+
+// NewErrUnexportedField creates new ErrUnexportedField.
+func NewErrUnexportedField(field string) error {
+	return &ErrUnexportedField{field}
+}
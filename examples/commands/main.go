@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/corpix/clistruct"
+	"github.com/urfave/cli"
+)
+
+type Flags struct {
+	Debug bool       `usage:"Enable debug mode"`
+	Say   SayCommand `cmd:"say" usage:"Say something"`
+}
+
+type SayCommand struct {
+	What  string `usage:"Tell me what to say" value:"I could say nothing"`
+	Times int    `usage:"How many times to say" value:"1"`
+}
+
+func (c *SayCommand) Run(context *cli.Context) error {
+	for n := 0; n < c.Times; n++ {
+		fmt.Println(
+			"Here is what I say:",
+			c.What,
+		)
+	}
+
+	return nil
+}
+
+func main() {
+	flags := &Flags{}
+
+	cliFlags, err := clistruct.FlagsFromStruct(flags)
+	if err != nil {
+		panic(err)
+	}
+	cliCommands, err := clistruct.CommandsFromStruct(flags)
+	if err != nil {
+		panic(err)
+	}
+
+	app := cli.NewApp()
+	app.Flags = cliFlags
+	app.Commands = cliCommands
+	app.Before = func(context *cli.Context) error {
+		return clistruct.FlagsToStruct(context, flags)
+	}
+
+	app.RunAndExitOnError()
+}
@@ -1,271 +1,271 @@
 package clistruct
 
 import (
+	"flag"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/urfave/cli"
 )
 
 const (
-	nameTag  = "name"
-	typeTag  = "type"
-	usageTag = "usage"
-	valueTag = "value"
+	nameTag      = "name"
+	typeTag      = "type"
+	usageTag     = "usage"
+	valueTag     = "value"
+	prefixTag    = "prefix"
+	envTag       = "env"
+	requiredTag  = "required"
+	layoutTag    = "layout"
+	cmdTag       = "cmd"
+	aliasesTag   = "aliases"
+	hiddenTag    = "hidden"
+	argTag       = "arg"
+	configTag    = "config"
+	appendTag    = "append"
+	embedTag     = "embed"
+	cliTag       = "cli"
+	delimiterTag = "delimiter"
 )
 
-const (
-	listDelimiter = ","
-	nameDelimiter = ","
+var (
+	// mappingTags are the tags which control the mapping,
+	// unexported fields having them are reported in strict mode.
+	mappingTags = []string{
+		nameTag, typeTag, usageTag, valueTag, prefixTag, envTag,
+		requiredTag, layoutTag, cmdTag, aliasesTag, hiddenTag,
+		argTag, configTag, appendTag, embedTag, delimiterTag,
+	}
 )
 
 const (
-	boolTypeTag        = "bool"
-	boolTTypeTag       = "boolt"
-	float64TypeTag     = "float64"
-	intTypeTag         = "int"
-	int64TypeTag       = "int64"
-	intSliceTypeTag    = "intslice"
-	int64SliceTypeTag  = "int64slice"
-	stringTypeTag      = "string"
-	stringSliceTypeTag = "stringslice"
-	uintTypeTag        = "uint"
-	uint64TypeTag      = "uint64"
-	durationTypeTag    = "duration"
-	genericTypeTag     = "generic"
+	listDelimiter    = ","
+	mapPairDelimiter = "="
+	nameDelimiter    = ","
+	pathDelimiter    = "."
+	envDelimiter     = "_"
+	skipTagValue     = "-"
+	embedPrefixValue = "prefix"
 )
 
-var (
-	boolType        = typeName(*new(bool))
-	uintType        = typeName(*new(uint))
-	uint64Type      = typeName(*new(uint64))
-	intType         = typeName(*new(int))
-	int64Type       = typeName(*new(int64))
-	float64Type     = typeName(*new(float64))
-	intSliceType    = typeName(*new([]int))
-	int64SliceType  = typeName(*new([]int64))
-	stringType      = typeName(*new(string))
-	stringSliceType = typeName(*new([]string))
-	durationType    = typeName(*new(time.Duration))
-)
+// FlagsFromStruct generates cli.Flag slice for github.com/urfave/cli
+// from the struct fields.
+// Options could be passed to change the mapping behavior.
+func FlagsFromStruct(v interface{}, opts ...Option) ([]cli.Flag, error) {
+	return defaultMapper.FlagsFromStruct(v, opts...)
+}
 
-var (
-	uintValueType        = uintType
-	uint64ValueType      = uint64Type
-	intValueType         = intType
-	int64ValueType       = int64Type
-	float64ValueType     = float64Type
-	intSliceValueType    = typeName(new(cli.IntSlice))
-	int64SliceValueType  = typeName(new(cli.Int64Slice))
-	stringValueType      = stringType
-	stringSliceValueType = typeName(new(cli.StringSlice))
-	durationValueType    = durationType
-)
+// FlagsFromStruct is like FlagsFromStruct function, but uses
+// the mapper options, opts are applied on top of them.
+func (m *Mapper) FlagsFromStruct(v interface{}, opts ...Option) ([]cli.Flag, error) {
+	err := checkValue(v)
+	if err != nil {
+		return nil, err
+	}
 
-type valueGetter func(*cli.Context, string) interface{}
+	return flagsFromStruct(v, m.options.with(opts))
+}
 
-var (
-	typeTagToFlag = map[string]cli.Flag{
-		boolTypeTag:        *new(cli.BoolFlag),
-		boolTTypeTag:       *new(cli.BoolTFlag),
-		uintTypeTag:        *new(cli.UintFlag),
-		uint64TypeTag:      *new(cli.Uint64Flag),
-		intTypeTag:         *new(cli.IntFlag),
-		int64TypeTag:       *new(cli.Int64Flag),
-		float64TypeTag:     *new(cli.Float64Flag),
-		intSliceTypeTag:    *new(cli.IntSliceFlag),
-		int64SliceTypeTag:  *new(cli.Int64SliceFlag),
-		stringTypeTag:      *new(cli.StringFlag),
-		stringSliceTypeTag: *new(cli.StringSliceFlag),
-		durationTypeTag:    *new(cli.DurationFlag),
-		genericTypeTag:     *new(cli.GenericFlag),
-	}
-
-	typeTagToFlagValueGetter = map[string]valueGetter{
-		boolTypeTag:        func(ctx *cli.Context, key string) interface{} { return ctx.Bool(key) },
-		boolTTypeTag:       func(ctx *cli.Context, key string) interface{} { return ctx.BoolT(key) },
-		uintTypeTag:        func(ctx *cli.Context, key string) interface{} { return ctx.Uint(key) },
-		uint64TypeTag:      func(ctx *cli.Context, key string) interface{} { return ctx.Uint64(key) },
-		intTypeTag:         func(ctx *cli.Context, key string) interface{} { return ctx.Int(key) },
-		int64TypeTag:       func(ctx *cli.Context, key string) interface{} { return ctx.Int64(key) },
-		float64TypeTag:     func(ctx *cli.Context, key string) interface{} { return ctx.Float64(key) },
-		intSliceTypeTag:    func(ctx *cli.Context, key string) interface{} { return ctx.IntSlice(key) },
-		int64SliceTypeTag:  func(ctx *cli.Context, key string) interface{} { return ctx.Int64Slice(key) },
-		stringTypeTag:      func(ctx *cli.Context, key string) interface{} { return ctx.String(key) },
-		stringSliceTypeTag: func(ctx *cli.Context, key string) interface{} { return ctx.StringSlice(key) },
-		durationTypeTag:    func(ctx *cli.Context, key string) interface{} { return ctx.Duration(key) },
-		genericTypeTag:     func(ctx *cli.Context, key string) interface{} { return ctx.Generic(key) },
-	}
-
-	typeToFlag = map[string]cli.Flag{
-		boolType:        typeTagToFlag[boolTypeTag],
-		uintType:        typeTagToFlag[uintTypeTag],
-		uint64Type:      typeTagToFlag[uint64TypeTag],
-		intType:         typeTagToFlag[intTypeTag],
-		int64Type:       typeTagToFlag[int64TypeTag],
-		float64Type:     typeTagToFlag[float64TypeTag],
-		intSliceType:    typeTagToFlag[intSliceTypeTag],
-		int64SliceType:  typeTagToFlag[int64SliceTypeTag],
-		stringType:      typeTagToFlag[stringTypeTag],
-		stringSliceType: typeTagToFlag[stringSliceTypeTag],
-		durationType:    typeTagToFlag[durationTypeTag],
-	}
-
-	typeToFlagValueGetter = map[string]valueGetter{
-		boolType:        typeTagToFlagValueGetter[boolTypeTag],
-		uintType:        typeTagToFlagValueGetter[uintTypeTag],
-		uint64Type:      typeTagToFlagValueGetter[uint64TypeTag],
-		intType:         typeTagToFlagValueGetter[intTypeTag],
-		int64Type:       typeTagToFlagValueGetter[int64TypeTag],
-		float64Type:     typeTagToFlagValueGetter[float64TypeTag],
-		intSliceType:    typeTagToFlagValueGetter[intSliceTypeTag],
-		int64SliceType:  typeTagToFlagValueGetter[int64SliceTypeTag],
-		stringType:      typeTagToFlagValueGetter[stringTypeTag],
-		stringSliceType: typeTagToFlagValueGetter[stringSliceTypeTag],
-		durationType:    typeTagToFlagValueGetter[durationTypeTag],
-	}
-
-	typesWithoutValues = map[string]bool{
-		boolType: true,
-	}
-
-	valueFromString = map[string]func(string) (interface{}, error){
-		uintValueType: func(v string) (interface{}, error) {
-			u, err := strconv.ParseUint(v, 10, 32)
-			if err != nil {
-				return nil, err
-			}
-			return uint(u), nil
-		},
-		uint64ValueType: func(v string) (interface{}, error) {
-			return strconv.ParseUint(v, 10, 64)
-		},
-		intValueType: func(v string) (interface{}, error) {
-			i, err := strconv.ParseInt(v, 10, 32)
+func flagsFromStruct(v interface{}, o *options) ([]cli.Flag, error) {
+	var (
+		flags []cli.Flag
+	)
+
+	if o.strict {
+		err := checkUnexportedStructFields(indirectType(reflect.TypeOf(v)), o)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := walkStruct(
+		v,
+		"",
+		o,
+		func(field reflect.StructField, value reflect.Value, prefix string) error {
+			flag, err := flagFromStructField(field, value, prefix, o)
 			if err != nil {
-				return nil, err
+				return err
 			}
-			return int(i), nil
-		},
-		int64ValueType: func(v string) (interface{}, error) {
-			return strconv.ParseInt(v, 10, 64)
-		},
-		float64ValueType: func(v string) (interface{}, error) {
-			return strconv.ParseFloat(v, 64)
-		},
-		intSliceValueType: func(v string) (interface{}, error) {
-			var (
-				ints     = strings.Split(v, listDelimiter)
-				intSlice = make(cli.IntSlice, len(ints))
-				i        int64
-				err      error
-			)
+			setFlagValueEnvVar(flag)
 
-			for k, v := range ints {
-				i, err = strconv.ParseInt(v, 10, 32)
-				if err != nil {
-					return nil, err
-				}
-				intSlice[k] = int(i)
-			}
+			flags = append(
+				flags,
+				indirectValue(reflect.ValueOf(flag)).
+					Interface().(cli.Flag),
+			)
 
-			return &intSlice, nil
+			return nil
 		},
-		int64SliceValueType: func(v string) (interface{}, error) {
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.config {
+		flags = append(flags, configFlag(o))
+	}
+
+	err = checkFlagNames(flags)
+	if err != nil {
+		return nil, err
+	}
+
+	return flags, nil
+}
+
+// FlagsToStruct folds a flags from context into the struct fields in v.
+// It returns ErrRequired if some of the flags marked as required
+// was not set, after all other fields were folded.
+// Positional arguments are folded into the fields with arg tag.
+// Folded values are checked against validation tags,
+// ErrValidation lists all the constraints violated.
+// Structs implementing Validator are validated after that.
+// With WithConfig option the config file is loaded before folding,
+// flags (or environment variables) which were set override config values
+// and config values override flag defaults.
+// With WithOnlySet option only the flags which was set are folded.
+func FlagsToStruct(context *cli.Context, v interface{}, opts ...Option) error {
+	return defaultMapper.FlagsToStruct(context, v, opts...)
+}
+
+// FlagsToStruct is like FlagsToStruct function, but uses
+// the mapper options, opts are applied on top of them.
+func (m *Mapper) FlagsToStruct(context *cli.Context, v interface{}, opts ...Option) error {
+	err := checkValue(v)
+	if err != nil {
+		return err
+	}
+
+	return flagsToStruct(context, v, m.options.with(opts))
+}
+
+func flagsToStruct(context *cli.Context, v interface{}, o *options) error {
+	loaded, err := configFromContext(context, v, o)
+	if err != nil {
+		return err
+	}
+
+	err = walkStruct(
+		v,
+		"",
+		o,
+		func(field reflect.StructField, value reflect.Value, prefix string) error {
 			var (
-				ints       = strings.Split(v, listDelimiter)
-				int64Slice = make(cli.Int64Slice, len(ints))
-				i          int64
-				err        error
+				names     = flagNamesFromStructField(field, prefix, o)
+				name      = names[0]
+				flagValue interface{}
+				err       error
 			)
 
-			for k, v := range ints {
-				i, err = strconv.ParseInt(v, 10, 64)
+			if (o.onlySet || loaded[name]) && !isFlagSet(context, names) {
+				// Config value wins over the flag default,
+				// struct value wins too if only set flags are folded.
+				return nil
+			}
+			if isPointerStructField(field, o) && !isFlagSet(context, names) &&
+				getStructFieldTag(field, valueTag) == "" && !flagHasDefaultInContext(context, name) {
+				// Pointer is left untouched if flag
+				// was not set and has no default.
+				return nil
+			}
+
+			if isBoundStructField(field, o) {
+				flagValue = boundValueFromContext(context, name)
+			} else {
+				flagValue, err = handlerFromContext(context, field, name, o).Get(context, name)
 				if err != nil {
-					return nil, err
+					return err
 				}
-				int64Slice[k] = i
+			}
+			if flagValue == nil {
+				// Generic flags without value has nothing to fold.
+				return nil
 			}
 
-			return &int64Slice, nil
-		},
-		stringValueType: func(v string) (interface{}, error) {
-			return v, nil
-		},
-		stringSliceValueType: func(v string) (interface{}, error) {
-			stringSlice := cli.StringSlice(strings.Split(v, listDelimiter))
-			return &stringSlice, nil
-		},
-		durationValueType: func(v string) (interface{}, error) {
-			return time.ParseDuration(v)
+			flagValue = stripSliceDefaults(context, field, name, flagValue, o)
+
+			return setStructFieldValue(field, value, flagValue, o)
 		},
+	)
+	if err != nil {
+		return err
 	}
-)
 
-// FlagsFromStruct generates cli.Flag slice for github.com/urfave/cli
-// from the struct fields.
-func FlagsFromStruct(v interface{}) ([]cli.Flag, error) {
-	err := checkValue(v)
+	err = checkRequiredFlags(context, v, loaded, o)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	err = argsToStruct(context, v, o)
+	if err != nil {
+		return err
 	}
 
-	return flagsFromStruct(v)
+	return validateStruct(v, o)
 }
 
-func flagsFromStruct(v interface{}) ([]cli.Flag, error) {
+// checkRequiredFlags checks that flags marked as required
+// was set or loaded from the config.
+func checkRequiredFlags(context *cli.Context, v interface{}, loaded map[string]bool, o *options) error {
 	var (
-		reflectType  = indirectType(reflect.TypeOf(v))
-		reflectValue = indirectValue(reflect.ValueOf(v))
-		flag         cli.Flag
-		flags        []cli.Flag
-		field        reflect.StructField
-		err          error
+		missing []string
 	)
 
-	if !reflectValue.IsValid() {
-		return nil, NewErrInvalid(v)
-	}
+	err := walkStruct(
+		v,
+		"",
+		o,
+		func(field reflect.StructField, value reflect.Value, prefix string) error {
+			if !isStructFieldTagTrue(field, requiredTag) {
+				return nil
+			}
 
-	err = shouldBeStruct(reflectValue)
+			names := flagNamesFromStructField(field, prefix, o)
+			if loaded[names[0]] || isFlagSet(context, names) {
+				return nil
+			}
+
+			missing = append(missing, names[0])
+			return nil
+		},
+	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	for n := 0; n < reflectValue.NumField(); n++ {
-		field = reflectType.Field(n)
-		if !isStructFieldExported(field) {
-			continue
-		}
-
-		flag, err = flagFromStructField(field)
-		if err != nil {
-			return nil, err
-		}
-
-		flags = append(
-			flags,
-			indirectValue(reflect.ValueOf(flag)).
-				Interface().(cli.Flag),
-		)
+	if len(missing) > 0 {
+		return NewErrRequired(missing)
 	}
 
-	return flags, nil
+	return nil
 }
 
-// FlagsToStruct folds a flags from context into the struct fields in v.
-func FlagsToStruct(context *cli.Context, v interface{}) error {
-	err := checkValue(v)
-	if err != nil {
-		return err
+// isFlagSet reports whether flag with any of the names
+// was set on the command line or with environment variable.
+func isFlagSet(context *cli.Context, names []string) bool {
+	for _, name := range names {
+		if context.IsSet(name) {
+			return true
+		}
 	}
 
-	return flagsToStruct(context, v)
+	return false
 }
 
-func flagsToStruct(context *cli.Context, v interface{}) error {
+// structFieldWalker is a function which is called by walkStruct
+// for each exported field which is not a nested struct.
+// Field value is settable, prefix is a flag name prefix of the
+// struct which contains the field.
+type structFieldWalker func(field reflect.StructField, value reflect.Value, prefix string) error
+
+// walkStruct calls fn for each exported field of the struct in v
+// descending into the nested structs.
+// Arguments are bound only to the fields of the top level struct,
+// so it returns ErrInvalidArgIndex for the nested fields with arg tag.
+func walkStruct(v interface{}, prefix string, o *options, fn structFieldWalker) error {
 	var (
 		reflectType  = indirectType(reflect.TypeOf(v))
 		reflectValue = indirectValue(reflect.ValueOf(v))
@@ -282,66 +282,223 @@ func flagsToStruct(context *cli.Context, v interface{}) error {
 		return err
 	}
 
-	for n := 0; n < reflectValue.NumField(); n++ {
-		field = reflectType.Field(n)
+	for _, field = range structFields(reflectType, o) {
 		if !isStructFieldExported(field) {
 			continue
 		}
+		if isArgStructField(field) && prefix != "" {
+			return NewErrInvalidArgIndex(field.Name, getStructFieldTag(field, argTag))
+		}
+		if isCommandStructField(field) || isArgStructField(field) {
+			// Commands has their own flags,
+			// arguments are not flags.
+			continue
+		}
 
-		err = setStructField(
-			v,
-			field.Name,
-			flagValueFromContext(
-				context,
-				flagValueGetterFromStructField(field),
-				flagNameFromStructField(field),
-			),
-		)
+		if isNestedStructField(field, o) {
+			err = walkStruct(
+				reflectValue.FieldByIndex(field.Index).Addr().Interface(),
+				flagPrefixFromStructField(field, prefix, o),
+				o,
+				fn,
+			)
+		} else {
+			err = fn(field, reflectValue.FieldByIndex(field.Index), prefix)
+		}
 		if err != nil {
 			return err
 		}
 	}
 
 	return nil
+}
+
+// structFields returns the fields of the struct type t
+// with the fields of the embedded structs promoted
+// following Go rules, so fields of the embedded struct
+// which are shadowed or ambiguous are not returned.
+// Embedded structs with `embed:"prefix"` tag are not promoted,
+// they are returned as the fields of struct type.
+// Fields tagged with `cli:"-"` or `name:"-"` are not returned.
+// Index of the promoted fields is relative to t.
+func structFields(t reflect.Type, o *options) []reflect.StructField {
+	var (
+		fields []reflect.StructField
+	)
+
+	for _, field := range reflect.VisibleFields(t) {
+		field = mapStructFieldTags(field, o)
+		if isEmbeddedStructField(field, o) {
+			// Fields are promoted instead.
+			continue
+		}
+		if isSkippedStructField(field) || !isPromotedStructField(t, field, o) {
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// isEmbeddedStructField reports whether field is an embedded
+// struct which fields should be promoted to the struct containing it.
+// Embedded pointers to structs are not promoted (they could be nil),
+// so they are rejected as the pointers which could not be mapped.
+func isEmbeddedStructField(field reflect.StructField, o *options) bool {
+	return field.Anonymous &&
+		!isSkippedStructField(field) &&
+		field.Type.Kind() == reflect.Struct &&
+		isNestedStructField(field, o) &&
+		getStructFieldTag(field, embedTag) != embedPrefixValue
+}
 
+// isSkippedStructField reports whether field
+// should not be mapped to the flags.
+func isSkippedStructField(field reflect.StructField) bool {
+	return getStructFieldTag(field, cliTag) == skipTagValue ||
+		getStructFieldTag(field, nameTag) == skipTagValue
 }
 
-func newFlagFromStructField(field reflect.StructField) cli.Flag {
+// mapStructFieldTags returns the field with the tags renamed
+// with WithTagName option available by their default names
+// (tags with the default names are hidden then) and the list
+// delimiter of the mapper set as the delimiter tag if field
+// has none, so the rest of the mapping (and type handlers)
+// could read the tags by their default names.
+func mapStructFieldTags(field reflect.StructField, o *options) reflect.StructField {
 	var (
-		t cli.Flag
+		tags []string
 	)
 
-	t = typeTagToFlag[getStructFieldTag(field, typeTag)]
-	if t == nil {
-		t = typeToFlag[field.Type.String()]
+	for tag, name := range o.tags {
+		value, ok := field.Tag.Lookup(name)
+		if !ok {
+			if _, ok = field.Tag.Lookup(tag); !ok {
+				continue
+			}
+			value = ""
+		}
+
+		tags = append(tags, tag+":"+strconv.Quote(value))
+	}
+
+	// Lookup returns the first tag with the name,
+	// so mapped tags are placed before the field tags.
+	tags = append(tags, string(field.Tag))
+	field.Tag = reflect.StructTag(strings.Join(tags, " "))
+
+	if o.listDelimiter != listDelimiter && field.Tag.Get(delimiterTag) == "" {
+		field.Tag = reflect.StructTag(
+			delimiterTag + ":" + strconv.Quote(o.listDelimiter) + " " + string(field.Tag),
+		)
+	}
+
+	return field
+}
+
+// isMappableStructField reports whether field could be mapped
+// to the flag without falling back to the generic flag.
+func isMappableStructField(field reflect.StructField, o *options) bool {
+	if _, ok := typeTagToHandler[getStructFieldTag(field, typeTag)]; ok {
+		return true
+	}
+	if isBoundStructField(field, o) {
+		return true
+	}
+
+	_, ok := o.types.handlerFromType(field.Type)
+	return ok
+}
+
+// checkUnexportedStructFields checks that unexported fields
+// of the struct type t (and of the nested structs) has no
+// mapping tags, because such fields could not be mapped.
+func checkUnexportedStructFields(t reflect.Type, o *options) error {
+	for _, field := range structFields(t, o) {
+		if !isStructFieldExported(field) {
+			for _, tag := range mappingTags {
+				if getStructFieldTag(field, tag) != "" {
+					return NewErrUnexportedField(field.Name)
+				}
+			}
+			continue
+		}
+
+		if isNestedStructField(field, o) && !isCommandStructField(field) {
+			err := checkUnexportedStructFields(field.Type, o)
+			if err != nil {
+				return err
+			}
+		}
 	}
-	if t == nil {
-		t = typeTagToFlag[genericTypeTag]
+
+	return nil
+}
+
+// isPromotedStructField reports whether field of t is
+// a field of t itself or it is promoted from the embedded
+// struct which fields should be promoted.
+func isPromotedStructField(t reflect.Type, field reflect.StructField, o *options) bool {
+	for n := 1; n < len(field.Index); n++ {
+		if !isEmbeddedStructField(mapStructFieldTags(t.FieldByIndex(field.Index[:n]), o), o) {
+			return false
+		}
 	}
 
-	return reflect.
-		New(
-			indirectType(
-				reflect.TypeOf(t),
-			),
-		).
-		Interface().(cli.Flag)
+	return true
 }
 
-func flagFromStructField(field reflect.StructField) (cli.Flag, error) {
+func flagFromStructField(field reflect.StructField, fieldValue reflect.Value, prefix string, o *options) (cli.Flag, error) {
+	if o.strict && !isMappableStructField(field, o) {
+		return nil, NewErrUnsupportedType(field.Name, field.Type.String())
+	}
+	if isPointerStructField(field, o) && !isMappableStructField(field, o) {
+		// Generic flag without value could not be applied,
+		// so pointers (like pointers to structs) which
+		// could not be mapped are rejected in any mode.
+		return nil, NewErrUnsupportedType(field.Name, field.Type.String())
+	}
+
 	var (
-		flag       cli.Flag
-		valueField reflect.Value
-		value      interface{}
-		err        error
+		handler      = handlerFromStructField(field, o)
+		structValue  = o.structDefaults && !fieldValue.IsZero()
+		defaultValue = fieldValue
+		flag         cli.Flag
+		value        interface{}
+		err          error
 	)
 
-	flag = newFlagFromStructField(field)
+	if isPointerStructField(field, o) && !fieldValue.IsNil() {
+		defaultValue = fieldValue.Elem()
+	}
+	if structValue {
+		if replacing, ok := replacingSliceHandler(handler.Flag(field)); ok {
+			// Number of the struct defaults could not be known
+			// when folding, so generic flag replacing them is used.
+			handler = replacing
+		}
+	}
+
+	switch {
+	case isBoundStructField(field, o):
+		flag = typeTagToHandler[genericTypeTag].Flag(field)
+	case structValue && handler.Parse == nil && defaultValue.Kind() == reflect.Bool && defaultValue.Bool():
+		// Bool flags has no value, so true
+		// default value is a BoolT flag.
+		flag = typeTagToHandler[boolTTypeTag].Flag(field)
+	default:
+		flag = handler.Flag(field)
+	}
 
 	err = setStructField(
 		flag,
 		"Name",
-		flagNameFromStructField(field),
+		strings.Join(
+			flagNamesFromStructField(field, prefix, o),
+			nameDelimiter+" ",
+		),
 	)
 	if err != nil {
 		return nil, err
@@ -349,25 +506,81 @@ func flagFromStructField(field reflect.StructField) (cli.Flag, error) {
 	err = setStructField(
 		flag,
 		"Usage",
-		getStructFieldTag(field, usageTag),
+		usageFromStructField(field),
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	envVar := flagEnvVarFromStructField(field, prefix, o)
+	if envVar != "" {
+		err = setStructField(flag, "EnvVar", envVar)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	valueString := getStructFieldTag(field, valueTag)
-	if valueString != "" && typesWithoutValues[field.Type.String()] {
-		return nil, NewErrFlagTypeCanNotHaveValue(field.Type.String())
+
+	if isBoundStructField(field, o) {
+		// Bound field value is the default value
+		// unless the value tag should be applied,
+		// field itself is written only when folding.
+		generic := newGenericValue(boundTargetFromStructField(field, fieldValue, o))
+		if valueString != "" && !structValue {
+			err = generic.Set(valueString)
+			if err != nil {
+				return nil, NewErrParse(
+					flagNameFromStructField(field, prefix, o),
+					valueString,
+					err,
+				)
+			}
+		}
+
+		err = setStructField(flag, "Value", generic)
+		if err != nil {
+			return nil, err
+		}
+
+		return flag, nil
 	}
-	if valueString != "" && !typesWithoutValues[field.Type.String()] {
-		valueField, err = getStructField(flag, "Value")
+
+	if structValue {
+		value, err = defaultValueFromStructField(field, defaultValue, flag, handler)
+		if err != nil {
+			return nil, NewErrParse(
+				flagNameFromStructField(field, prefix, o),
+				formatValue(defaultValue.Interface()),
+				err,
+			)
+		}
+		if value == nil {
+			return flag, nil
+		}
+
+		err = setStructField(flag, "Value", value)
 		if err != nil {
 			return nil, err
 		}
-		value, err = getValueFromString(
-			valueString,
-			valueField.Type(),
-		)
+
+		return flag, nil
+	}
+
+	if valueString != "" && handler.Parse == nil {
+		return nil, NewErrFlagTypeCanNotHaveValue(field.Type.String())
+	}
+	if valueString != "" {
+		value, err = handler.Parse(field, valueString)
+		if err != nil {
+			return nil, NewErrParse(
+				flagNameFromStructField(field, prefix, o),
+				valueString,
+				err,
+			)
+		}
+
+		err = checkDefaultValue(field, value, o)
 		if err != nil {
 			return nil, err
 		}
@@ -381,43 +594,476 @@ func flagFromStructField(field reflect.StructField) (cli.Flag, error) {
 	return flag, nil
 }
 
-func flagNameFromStructField(field reflect.StructField) string {
-	name := getStructFieldTag(field, nameTag)
+// flagNameFromStructField returns a primary flag name
+// which is used to lookup the flag value in context.
+func flagNameFromStructField(field reflect.StructField, prefix string, o *options) string {
+	return flagNamesFromStructField(field, prefix, o)[0]
+}
+
+// flagNamesFromStructField returns all flag names
+// (the primary name followed by aliases) for the field.
+// Aliases are listed in the name tag after the primary
+// name, for example `name:"verbose,v"`.
+// Without name tag the field name is mapped with the name mapper.
+func flagNamesFromStructField(field reflect.StructField, prefix string, o *options) []string {
+	var (
+		tag   = getStructFieldTag(field, nameTag)
+		names []string
+	)
+
+	for _, name := range strings.Split(tag, nameDelimiter) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		names = append(names, joinFlagPath(prefix, name))
+	}
+
+	if len(names) == 0 {
+		return []string{joinFlagPath(prefix, o.nameMapper(field.Name))}
+	}
+
+	return names
+}
+
+// checkFlagNames checks that each flag name or alias
+// is used only once. Help and version flags are not checked,
+// because application could hide them (so `name:"verbose,v"`
+// needs the version flag to be hidden or renamed).
+func checkFlagNames(flags []cli.Flag) error {
+	seen := map[string]bool{}
+
+	for _, flag := range flags {
+		for _, name := range strings.Split(flag.GetName(), nameDelimiter) {
+			name = strings.TrimSpace(name)
+			if seen[name] {
+				return NewErrDuplicateFlagName(name)
+			}
+			seen[name] = true
+		}
+	}
+
+	return nil
+}
+
+// flagEnvVarFromStructField returns a comma separated list of
+// environment variables which could be used to set the flag.
+// Explicit env tag always wins over the automatic name,
+// env tag with "-" value disables environment for the field.
+func flagEnvVarFromStructField(field reflect.StructField, prefix string, o *options) string {
+	envVar := getStructFieldTag(field, envTag)
+
+	switch {
+	case envVar == skipTagValue:
+		return ""
+	case envVar != "":
+		return envVar
+	case o.envAuto:
+		return envNameFromFlagName(
+			o.envPrefix,
+			flagNameFromStructField(field, prefix, o),
+		)
+	default:
+		return ""
+	}
+}
+
+// flagPrefixFromStructField returns a prefix for the flags
+// generated from the nested struct field.
+// Field name is mapped with the name mapper,
+// prefix could be overridden with the prefix tag.
+func flagPrefixFromStructField(field reflect.StructField, prefix string, o *options) string {
+	segment := getStructFieldTag(field, prefixTag)
+
+	if segment == "" {
+		segment = o.nameMapper(field.Name)
+	}
+
+	return joinFlagPath(prefix, segment)
+}
+
+// isNestedStructField reports whether field should be
+// walked as a group of flags instead of being a single flag.
+func isNestedStructField(field reflect.StructField, o *options) bool {
+	if field.Type.Kind() != reflect.Struct {
+		return false
+	}
+	if getStructFieldTag(field, typeTag) != "" {
+		return false
+	}
+	if isGenericType(field.Type) {
+		return false
+	}
+
+	_, ok := o.types.handlerFromType(field.Type)
+	return !ok
+}
+
+// isBoundStructField reports whether field value parses
+// strings by itself, so the flag value should be bound
+// to the field.
+func isBoundStructField(field reflect.StructField, o *options) bool {
+	t := field.Type
+	if isPointerStructField(field, o) {
+		t = t.Elem()
+	}
+
+	tag := getStructFieldTag(field, typeTag)
+	if tag != "" && tag != genericTypeTag {
+		return false
+	}
+	if _, ok := o.types.lookup(t); ok {
+		return false
+	}
+
+	return isGenericType(t)
+}
+
+// isPointerStructField reports whether field is a pointer
+// which has no handler registered, so it is mapped to the flag
+// of the type it points to. Pointer is allocated only when
+// the flag was set or has a default value, otherwise it is left nil.
+func isPointerStructField(field reflect.StructField, o *options) bool {
+	if field.Type.Kind() != reflect.Ptr {
+		return false
+	}
+
+	_, ok := o.types.lookup(field.Type)
+	return !ok
+}
+
+// boundTargetFromStructField returns an addressable value
+// which is written by the flag bound to the field.
+// Flag is bound to the copy of the field value, so the field
+// is written only when the flags are folded (and values loaded
+// from the config or set before folding are kept unless the flag was set).
+// Pointer fields are bound to the copy of the value they point to,
+// so pointer is not allocated until the flags are folded.
+func boundTargetFromStructField(field reflect.StructField, fieldValue reflect.Value, o *options) reflect.Value {
+	if !isPointerStructField(field, o) {
+		target := reflect.New(field.Type).Elem()
+		target.Set(fieldValue)
+
+		return target
+	}
 
-	if name == "" {
-		return strings.ToLower(field.Name)
+	target := reflect.New(field.Type.Elem()).Elem()
+	if !fieldValue.IsNil() {
+		target.Set(fieldValue.Elem())
 	}
 
-	return strings.Split(
-		name,
-		nameDelimiter,
-	)[0]
+	return target
 }
 
-func flagValueGetterFromStructField(field reflect.StructField) valueGetter {
+// setStructFieldValue sets the value of the field,
+// overflow errors are annotated with the field name.
+// Pointer fields are set with the pointer to the new value.
+func setStructFieldValue(field reflect.StructField, fieldValue reflect.Value, value interface{}, o *options) error {
 	var (
-		getter = typeTagToFlagValueGetter[getStructFieldTag(field, typeTag)]
+		target   = fieldValue
+		allocate = isPointerStructField(field, o) && reflect.TypeOf(value) != field.Type
 	)
 
-	if getter == nil {
-		getter = typeToFlagValueGetter[field.Type.String()]
+	if allocate {
+		target = reflect.New(field.Type.Elem()).Elem()
 	}
-	if getter == nil {
-		getter = typeTagToFlagValueGetter[genericTypeTag]
+
+	err := setValue(target, value)
+	if e, ok := err.(*ErrOverflow); ok {
+		return NewErrOverflow(field.Name, e.value, e.t)
+	}
+	if err != nil {
+		return err
+	}
+
+	if allocate {
+		fieldValue.Set(target.Addr())
 	}
 
-	return getter
+	return nil
 }
 
-func flagValueFromContext(context *cli.Context, getter valueGetter, name string) interface{} {
-	return getter(context, name)
+// checkDefaultValue checks that default value parsed
+// for the flag fits into the field type.
+func checkDefaultValue(field reflect.StructField, value interface{}, o *options) error {
+	if getter, ok := value.(flag.Getter); ok {
+		value = getter.Get()
+	}
+
+	t := field.Type
+	if isPointerStructField(field, o) {
+		t = t.Elem()
+	}
+
+	_, err := convertValue(reflect.ValueOf(value), t)
+	if e, ok := err.(*ErrOverflow); ok {
+		return NewErrOverflow(field.Name, e.value, e.t)
+	}
+
+	return nil
 }
 
-func getValueFromString(v string, targetType reflect.Type) (interface{}, error) {
-	getter, ok := valueFromString[targetType.String()]
+// defaultValueFromStructField returns a default value for the flag
+// from the value of the field, it is converted to the type
+// of the flag Value if possible, or formatted and parsed
+// with the handler otherwise.
+// Nil value means the flag could not have a default value.
+func defaultValueFromStructField(field reflect.StructField, fieldValue reflect.Value, flag cli.Flag, handler TypeHandler) (interface{}, error) {
+	var (
+		target = indirectValue(reflect.ValueOf(flag)).FieldByName("Value")
+		t      reflect.Type
+	)
+
+	if !target.IsValid() {
+		return nil, nil
+	}
+
+	t = target.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Interface {
+		value, err := convertValue(fieldValue, t)
+		if err == nil {
+			if target.Kind() != reflect.Ptr {
+				return value.Interface(), nil
+			}
+
+			ptr := reflect.New(t)
+			ptr.Elem().Set(value)
+			return ptr.Interface(), nil
+		}
+	}
+
+	if setter, ok := target.Interface().(valueSetter); ok {
+		return setter, setter.setValue(fieldValue)
+	}
+	if handler.Parse == nil || handler.Format == nil {
+		return nil, nil
+	}
+
+	return handler.Parse(field, handler.Format(fieldValue.Interface()))
+}
+
+// stripSliceDefaults removes the default values from the
+// values of slice flag which was set by the user, because
+// slice flags of github.com/urfave/cli append the values to
+// the defaults (or to the environment variable values which
+// replace the defaults). Generic slice flags replace the defaults
+// by themselves, so only the environment variable values are removed.
+// Values are kept as is for the fields with append tag.
+func stripSliceDefaults(context *cli.Context, field reflect.StructField, name string, flagValue interface{}, o *options) interface{} {
+	var (
+		values    = reflect.ValueOf(flagValue)
+		appending bool
+		delimiter string
+		base      int
+	)
+
+	if values.Kind() != reflect.Slice || isStructFieldTagTrue(field, appendTag) {
+		return flagValue
+	}
+
+	appending = isAppendingSliceFlag(flagFromContext(context, name))
+	delimiter = listDelimiterFromStructField(field)
+	if appending {
+		// github.com/urfave/cli splits environment
+		// variable values by comma.
+		delimiter = listDelimiter
+	}
+
+	envValue, ok := flagEnvValueFromContext(context, name)
+	switch {
+	case ok:
+		base = len(strings.Split(envValue, delimiter))
+	case appending:
+		base = defaultSliceLength(field, o)
+	}
+
+	if values.Len() <= base {
+		return flagValue
+	}
+
+	return values.Slice(base, values.Len()).Interface()
+}
+
+// isAppendingSliceFlag reports whether flag is a slice flag
+// of github.com/urfave/cli which appends the values to the defaults.
+func isAppendingSliceFlag(flag cli.Flag) bool {
+	switch flag.(type) {
+	case *cli.IntSliceFlag, *cli.Int64SliceFlag, *cli.StringSliceFlag,
+		cli.IntSliceFlag, cli.Int64SliceFlag, cli.StringSliceFlag:
+		return true
+	default:
+		return false
+	}
+}
+
+// replacingSliceHandler returns a handler of the generic slice
+// flag (which replaces the defaults by itself) for the slice flag
+// of github.com/urfave/cli which appends the values to the defaults.
+func replacingSliceHandler(flag cli.Flag) (TypeHandler, bool) {
+	switch flag.(type) {
+	case *cli.IntSliceFlag, cli.IntSliceFlag:
+		return newSliceHandler(intType, parseInt, formatScalar), true
+	case *cli.Int64SliceFlag, cli.Int64SliceFlag:
+		return newSliceHandler(int64Type, parseInt64, formatScalar), true
+	case *cli.StringSliceFlag, cli.StringSliceFlag:
+		return newSliceHandler(stringType, parseString, formatScalar), true
+	default:
+		return TypeHandler{}, false
+	}
+}
+
+// handlerFromContext returns a handler of the field which
+// could read the value of the flag with the name from context.
+// Slice flags which has the struct defaults are generic ones,
+// see replacingSliceHandler.
+func handlerFromContext(context *cli.Context, field reflect.StructField, name string, o *options) TypeHandler {
+	handler := handlerFromStructField(field, o)
+
+	if isAppendingSliceFlag(handler.Flag(field)) && !isAppendingSliceFlag(flagFromContext(context, name)) {
+		handler.Get = getterValueFromContext
+	}
+
+	return handler
+}
+
+// defaultSliceLength returns the number of the default
+// values of the slice flag from the value tag of the field.
+// Struct defaults are not counted, because slice flags
+// which has them replace the defaults by themselves.
+func defaultSliceLength(field reflect.StructField, o *options) int {
+	valueString := getStructFieldTag(field, valueTag)
+	if valueString == "" {
+		return 0
+	}
+
+	value, err := o.types.valueFromString(field, field.Type, valueString)
+	if err != nil || value.Kind() != reflect.Slice {
+		return 0
+	}
+
+	return value.Len()
+}
+
+// flagEnvValueFromContext returns the value of the environment
+// variable which was used to set the flag with the name.
+func flagEnvValueFromContext(context *cli.Context, name string) (string, bool) {
+	flag := flagFromContext(context, name)
+	if flag == nil {
+		return "", false
+	}
+
+	envVar := indirectValue(reflect.ValueOf(flag)).FieldByName("EnvVar")
+	if !envVar.IsValid() || envVar.Kind() != reflect.String {
+		return "", false
+	}
+
+	return lookupEnvVar(envVar.String())
+}
+
+// lookupEnvVar returns the value of the first environment variable
+// which is set from the comma separated list of variables,
+// just like github.com/urfave/cli does.
+func lookupEnvVar(envVar string) (string, bool) {
+	if envVar == "" {
+		return "", false
+	}
+
+	for _, env := range strings.Split(envVar, listDelimiter) {
+		value, ok := os.LookupEnv(strings.TrimSpace(env))
+		if ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// setFlagValueEnvVar passes the environment variables
+// of the flag to the flag value if it needs them.
+func setFlagValueEnvVar(flag cli.Flag) {
+	var (
+		value  = indirectValue(reflect.ValueOf(flag))
+		envVar = value.FieldByName("EnvVar")
+		target = value.FieldByName("Value")
+	)
+
+	if !envVar.IsValid() || !target.IsValid() || !target.CanInterface() {
+		return
+	}
+
+	setter, ok := target.Interface().(envVarSetter)
 	if ok {
-		return getter(v)
+		setter.setEnvVar(envVar.String())
+	}
+}
+
+// flagHasDefaultInContext reports whether the flag with the name
+// has a non zero default value. Defaults of the generic flags
+// could not be detected, so they are reported as missing.
+func flagHasDefaultInContext(context *cli.Context, name string) bool {
+	flag := flagFromContext(context, name)
+	if _, ok := flag.(cli.BoolTFlag); ok {
+		return true
+	}
+	if flag == nil {
+		return false
+	}
+
+	value := indirectValue(reflect.ValueOf(flag)).FieldByName("Value")
+	if !value.IsValid() || value.Kind() == reflect.Interface {
+		return false
+	}
+
+	return !value.IsZero()
+}
+
+// flagFromContext returns the definition of the flag with the name
+// from the command of context (or application if there is no command).
+func flagFromContext(context *cli.Context, name string) cli.Flag {
+	flags := context.App.Flags
+	if context.Command.Name != "" {
+		flags = context.Command.Flags
+	}
+
+	for _, flag := range flags {
+		for _, flagName := range strings.Split(flag.GetName(), nameDelimiter) {
+			if strings.TrimSpace(flagName) == name {
+				return flag
+			}
+		}
+	}
+
+	return nil
+}
+
+// boundValueFromContext returns a value of the bound field
+// from the flag with the name.
+func boundValueFromContext(context *cli.Context, name string) interface{} {
+	generic, ok := context.Generic(name).(cli.Generic)
+	if !ok {
+		return nil
+	}
+
+	return genericValueTarget(generic)
+}
+
+func envNameFromFlagName(prefix string, name string) string {
+	return prefix + strings.ToUpper(
+		strings.NewReplacer(
+			pathDelimiter, envDelimiter,
+			"-", envDelimiter,
+		).Replace(name),
+	)
+}
+
+func joinFlagPath(prefix string, name string) string {
+	if prefix == "" {
+		return name
 	}
 
-	return v, nil
+	return prefix + pathDelimiter + name
 }
@@ -1,6 +1,9 @@
 package clistruct
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
@@ -8,6 +11,39 @@ import (
 	"github.com/urfave/cli"
 )
 
+type testGeneric struct {
+	value string
+}
+
+func (g *testGeneric) Set(v string) error {
+	g.value = v
+	return nil
+}
+
+func (g *testGeneric) String() string {
+	return g.value
+}
+
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	case "error":
+		*l = 2
+	default:
+		return fmt.Errorf("unknown level %q", text)
+	}
+	return nil
+}
+
+func (l testLevel) MarshalText() ([]byte, error) {
+	return []byte([]string{"debug", "info", "error"}[l]), nil
+}
+
 func TestFlagsFromStructWithTags(t *testing.T) {
 	type custom struct{}
 
@@ -125,8 +161,6 @@ func TestFlagsFromStructBoolThasNoValue(t *testing.T) {
 }
 
 func TestFlagsRoStructWithTags(t *testing.T) {
-	// FIXME: generic types is nil in .cli for some reason oO
-	//type custom struct{}
 	type Sample struct {
 		Bool        bool          `name:"bool"        type:"bool"        usage:"hello"`
 		BoolT       bool          `name:"boolt"       type:"boolt"       usage:"hello"`
@@ -140,7 +174,7 @@ func TestFlagsRoStructWithTags(t *testing.T) {
 		String      string        `name:"string"      type:"string"      usage:"hello" value:"some string"`
 		StringSlice []string      `name:"stringslice" type:"stringslice" usage:"hello" value:"some,string,slice"`
 		Duration    time.Duration `name:"duration"    type:"duration"    usage:"hello" value:"2h1m10s"`
-		//Custom      custom        `name:"custom"      type:"generic"     usage:"hello"`
+		Custom      testGeneric   `name:"custom"      type:"generic"     usage:"hello"`
 	}
 
 	sample := &Sample{}
@@ -176,6 +210,7 @@ func TestFlagsRoStructWithTags(t *testing.T) {
 			"--string", "string some",
 			"--stringslice", "and", "--stringslice", "others",
 			"--duration", "1h",
+			"--custom", "some custom",
 		},
 	)
 	if err != nil {
@@ -197,12 +232,854 @@ func TestFlagsRoStructWithTags(t *testing.T) {
 		Int:         10,
 		Int64:       -10,
 		Float64:     10.05,
-		IntSlice:    []int{1, 2, 3, -1, 5, 4},
-		Int64Slice:  []int64{1, 2, 3, -1, 5, 4},
+		IntSlice:    []int{5, 4},
+		Int64Slice:  []int64{5, 4},
 		String:      "string some",
-		StringSlice: []string{"some", "string", "slice", "and", "others"},
+		StringSlice: []string{"and", "others"},
 		Duration:    time.Hour,
+		Custom:      testGeneric{"some custom"},
+	}
+
+	assert.EqualValues(t, expectedSample, sample)
+}
+
+func TestFlagsFromStructNested(t *testing.T) {
+	type db struct {
+		Host string `usage:"hello" value:"localhost"`
+		Port int    `usage:"hello" value:"5432"`
+	}
+
+	sample := struct {
+		Debug bool `usage:"hello"`
+		DB    db
+		Cache struct {
+			Size int `usage:"hello"`
+		} `prefix:"lru"`
+	}{}
+	flags := []cli.Flag{
+		cli.BoolFlag{Name: "debug", Usage: "hello"},
+		cli.StringFlag{Name: "db.host", Usage: "hello", Value: "localhost"},
+		cli.IntFlag{Name: "db.port", Usage: "hello", Value: 5432},
+		cli.IntFlag{Name: "lru.size", Usage: "hello"},
+	}
+
+	result, err := FlagsFromStruct(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.EqualValues(t, flags, result)
+}
+
+func TestFlagsToStructNested(t *testing.T) {
+	type db struct {
+		Host string `value:"localhost"`
+		Port int    `value:"5432"`
+	}
+	type Sample struct {
+		DB     db
+		Backup struct {
+			DB db
+		}
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(
+		flags,
+		"--db.port", "6432",
+		"--backup.db.host", "backup",
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expectedSample := &Sample{}
+	expectedSample.DB = db{Host: "localhost", Port: 6432}
+	expectedSample.Backup.DB = db{Host: "backup", Port: 5432}
+
+	assert.EqualValues(t, expectedSample, sample)
+}
+
+// runApp runs an application with flags and arguments
+// returning a context which was passed to the action.
+func runApp(flags []cli.Flag, args ...string) (*cli.Context, error) {
+	var (
+		context *cli.Context
+		app     = cli.NewApp()
+	)
+
+	app.Writer = ioutil.Discard
+	app.Flags = flags
+	app.Action = func(c *cli.Context) error {
+		context = c
+		return nil
+	}
+
+	// XXX: First argument is a program name, so it is empty.
+	err := app.Run(append([]string{""}, args...))
+	if err != nil {
+		return nil, err
+	}
+
+	return context, nil
+}
+
+func TestFlagsFromStructEnv(t *testing.T) {
+	sample := struct {
+		Host  string `env:"DB_HOST,DATABASE_HOST"`
+		Port  int
+		Debug bool `env:"-"`
+		DB    struct {
+			Name string
+		}
+	}{}
+
+	result, err := FlagsFromStruct(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.EqualValues(
+		t,
+		[]cli.Flag{
+			cli.StringFlag{Name: "host", EnvVar: "DB_HOST,DATABASE_HOST"},
+			cli.IntFlag{Name: "port"},
+			cli.BoolFlag{Name: "debug"},
+			cli.StringFlag{Name: "db.name"},
+		},
+		result,
+	)
+
+	result, err = FlagsFromStruct(&sample, WithEnvPrefix("MYAPP_"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.EqualValues(
+		t,
+		[]cli.Flag{
+			cli.StringFlag{Name: "host", EnvVar: "DB_HOST,DATABASE_HOST"},
+			cli.IntFlag{Name: "port", EnvVar: "MYAPP_PORT"},
+			cli.BoolFlag{Name: "debug"},
+			cli.StringFlag{Name: "db.name", EnvVar: "MYAPP_DB_NAME"},
+		},
+		result,
+	)
+}
+
+func TestFlagsToStructEnv(t *testing.T) {
+	type Sample struct {
+		Host string `env:"CLISTRUCT_TEST_HOST" value:"localhost"`
+		DB   struct {
+			Port int `value:"5432"`
+		}
+	}
+
+	os.Setenv("CLISTRUCT_TEST_HOST", "example.com")
+	defer os.Unsetenv("CLISTRUCT_TEST_HOST")
+	os.Setenv("CLISTRUCT_TEST_DB_PORT", "6432")
+	defer os.Unsetenv("CLISTRUCT_TEST_DB_PORT")
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample, WithEnvPrefix("CLISTRUCT_TEST_"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
 	}
 
+	expectedSample := &Sample{Host: "example.com"}
+	expectedSample.DB.Port = 6432
+
 	assert.EqualValues(t, expectedSample, sample)
 }
+
+func TestFlagsToStructOnlySet(t *testing.T) {
+	type Sample struct {
+		Host  string    `env:"CLISTRUCT_TEST_ONLY_SET_HOST" value:"localhost"`
+		Port  int       `value:"5432"`
+		Debug bool      `name:"debug,d"`
+		Tags  []string  `value:"a"`
+		Name  string    `arg:"0" value:"app"`
+		Level testLevel `value:"info"`
+		Mode  testLevel `value:"info"`
+	}
+
+	os.Setenv("CLISTRUCT_TEST_ONLY_SET_HOST", "example.com")
+	defer os.Unsetenv("CLISTRUCT_TEST_ONLY_SET_HOST")
+
+	sample := &Sample{
+		Host:  "db.local",
+		Port:  6432,
+		Tags:  []string{"x", "y"},
+		Name:  "service",
+		Level: 2,
+		Mode:  2,
+	}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// Defaults of the bound fields are not written into the struct.
+	assert.EqualValues(t, 2, sample.Level)
+
+	context, err := runApp(flags, "-d", "--mode", "debug")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample, WithOnlySet())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(
+		t,
+		&Sample{
+			Host:  "example.com",
+			Port:  6432,
+			Debug: true,
+			Tags:  []string{"x", "y"},
+			Name:  "service",
+			Level: 2,
+			Mode:  0,
+		},
+		sample,
+	)
+}
+
+func TestFlagsFromStructStructDefaults(t *testing.T) {
+	type Sample struct {
+		Host    string `value:"localhost"`
+		Port    int16
+		Debug   bool
+		Hosts   []string
+		Ports   []int32
+		Weights []float64
+		Timeout time.Duration
+		Start   time.Time `layout:"2006-01-02"`
+		Labels  map[string]int
+		Level   testLevel `value:"error"`
+		Name    string    `value:"app"`
+	}
+
+	sample := &Sample{
+		Host:    "example.com",
+		Port:    8080,
+		Debug:   true,
+		Hosts:   []string{"a", "b,c"},
+		Ports:   []int32{80, 443},
+		Weights: []float64{0.5},
+		Timeout: 5 * time.Second,
+		Start:   time.Date(2017, 7, 12, 0, 0, 0, 0, time.UTC),
+		Labels:  map[string]int{"x": 1},
+		Level:   testLevel(1),
+	}
+	expected := *sample
+	expected.Name = "app"
+
+	flags, err := FlagsFromStruct(sample, WithStructDefaults())
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result := &Sample{}
+	err = FlagsToStruct(context, result)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, &expected, result)
+
+	context, err = runApp(flags, "--debug=false", "--port", "80")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	result = &Sample{}
+	err = FlagsToStruct(context, result)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, false, result.Debug)
+	assert.EqualValues(t, 80, result.Port)
+
+	// Slice values set by the user replace the struct defaults
+	// whatever struct the flags are folded into.
+	for _, opts := range [][]Option{nil, {WithStructDefaults()}} {
+		flags, err = FlagsFromStruct(
+			&Sample{Hosts: []string{"a"}, Ports: []int32{80, 443}},
+			WithStructDefaults(),
+		)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		// Slice flags of github.com/urfave/cli append to the defaults,
+		// so the generic ones are used for the struct defaults.
+		assert.IsType(t, cli.GenericFlag{}, flags[3])
+		assert.IsType(t, cli.GenericFlag{}, flags[4])
+
+		context, err = runApp(flags, "--hosts", "b", "--ports", "8080")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		result = &Sample{}
+		err = FlagsToStruct(context, result, opts...)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.EqualValues(t, []string{"b"}, result.Hosts)
+		assert.EqualValues(t, []int32{8080}, result.Ports)
+	}
+}
+
+func TestFlagsToStructSliceDefaults(t *testing.T) {
+	type Sample struct {
+		Ints            []int           `value:"1,2"`
+		IntsAppend      []int           `value:"1,2" append:"true"`
+		Durations       []time.Duration `value:"1s"`
+		DurationsAppend []time.Duration `value:"1s" append:"true"`
+		Env             []string        `value:"a" env:"CLISTRUCT_TEST_SLICE"`
+	}
+
+	os.Setenv("CLISTRUCT_TEST_SLICE", "b,c")
+	defer os.Unsetenv("CLISTRUCT_TEST_SLICE")
+
+	for _, c := range []struct {
+		args     []string
+		expected Sample
+	}{
+		{
+			nil,
+			Sample{
+				Ints:            []int{1, 2},
+				IntsAppend:      []int{1, 2},
+				Durations:       []time.Duration{time.Second},
+				DurationsAppend: []time.Duration{time.Second},
+				Env:             []string{"b", "c"},
+			},
+		},
+		{
+			[]string{
+				"--ints", "3",
+				"--intsappend", "3",
+				"--durations", "1m",
+				"--durationsappend", "1m",
+				"--env", "d",
+			},
+			Sample{
+				Ints:            []int{3},
+				IntsAppend:      []int{1, 2, 3},
+				Durations:       []time.Duration{time.Minute},
+				DurationsAppend: []time.Duration{time.Second, time.Minute},
+				Env:             []string{"d"},
+			},
+		},
+	} {
+		sample := &Sample{}
+
+		// Slice flags keep the values between the runs,
+		// so each run needs its own flags.
+		flags, err := FlagsFromStruct(sample)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		context, err := runApp(flags, c.args...)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = FlagsToStruct(context, sample)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		assert.EqualValues(t, &c.expected, sample)
+	}
+}
+
+type testCommonFlags struct {
+	Verbose bool
+	Host    string `value:"localhost"`
+	Port    int    `value:"80"`
+	Timeout time.Duration
+}
+
+type testRetryFlags struct {
+	Retries int `value:"3"`
+	Timeout time.Duration
+}
+
+type TestDB struct {
+	Name string `value:"app"`
+}
+
+func TestFlagsStructEmbedded(t *testing.T) {
+	type Sample struct {
+		testCommonFlags
+		testRetryFlags
+		TestDB `embed:"prefix" prefix:"db"`
+		Port   int `value:"8080"`
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	names := []string{}
+	for _, flag := range flags {
+		names = append(names, flag.GetName())
+	}
+	// Port is shadowed, Timeout is ambiguous.
+	assert.EqualValues(t, []string{"verbose", "host", "retries", "db.name", "port"}, names)
+
+	context, err := runApp(flags, "--verbose", "--db.name", "test")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := &Sample{Port: 8080}
+	expected.Verbose = true
+	expected.Host = "localhost"
+	expected.Retries = 3
+	expected.Name = "test"
+
+	assert.EqualValues(t, expected, sample)
+}
+
+func TestFlagsFromStructEmbeddedPointer(t *testing.T) {
+	type CommonFlags struct {
+		Verbose bool
+	}
+
+	sample := &struct {
+		*CommonFlags
+		Host string
+	}{}
+
+	for _, opts := range [][]Option{nil, {WithStrict()}} {
+		_, err := FlagsFromStruct(sample, opts...)
+		assert.EqualValues(
+			t,
+			NewErrUnsupportedType("CommonFlags", "*clistruct.CommonFlags"),
+			err,
+		)
+	}
+}
+
+func TestFlagsStructSkip(t *testing.T) {
+	type Sample struct {
+		Host      string    `value:"localhost"`
+		CreatedAt time.Time `cli:"-"`
+		Secret    string    `name:"-" value:"secret"`
+		TestDB    `cli:"-"`
+	}
+
+	sample := &Sample{Secret: "keep"}
+	sample.Name = "keep"
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.EqualValues(t, []cli.Flag{cli.StringFlag{Name: "host", Value: "localhost"}}, flags)
+
+	context, err := runApp(flags)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expected := &Sample{Host: "localhost", Secret: "keep"}
+	expected.Name = "keep"
+
+	assert.EqualValues(t, expected, sample)
+}
+
+func TestFlagsFromStructStrict(t *testing.T) {
+	for _, c := range []struct {
+		sample interface{}
+		err    error
+	}{
+		{
+			&struct {
+				Events chan string
+			}{},
+			NewErrUnsupportedType("Events", "chan string"),
+		},
+		{
+			&struct {
+				Nested struct {
+					Handler func()
+				}
+			}{},
+			NewErrUnsupportedType("Handler", "func()"),
+		},
+		{
+			&struct {
+				host string `value:"localhost"`
+			}{},
+			NewErrUnexportedField("host"),
+		},
+		{
+			&struct {
+				Events chan string `cli:"-"`
+				host   string
+				Custom testGeneric
+			}{},
+			nil,
+		},
+	} {
+		_, err := FlagsFromStruct(c.sample, WithStrict())
+		assert.EqualValues(t, c.err, err)
+	}
+}
+
+func TestFlagsFromStructAliases(t *testing.T) {
+	sample := struct {
+		Verbose bool   `name:"verbose,v"  usage:"hello"`
+		Output  string `name:"output, o"  usage:"hello"`
+		DB      struct {
+			Host string `name:"host,h"`
+		}
+	}{}
+	flags := []cli.Flag{
+		cli.BoolFlag{Name: "verbose, v", Usage: "hello"},
+		cli.StringFlag{Name: "output, o", Usage: "hello"},
+		cli.StringFlag{Name: "db.host, db.h"},
+	}
+
+	result, err := FlagsFromStruct(&sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.EqualValues(t, flags, result)
+}
+
+func TestFlagsToStructAliases(t *testing.T) {
+	type Sample struct {
+		Debug  bool   `name:"debug,d"`
+		Output string `name:"output,o"`
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags, "-d", "--o", "out.txt")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, &Sample{Debug: true, Output: "out.txt"}, sample)
+}
+
+func TestFlagsToStructAliasesVersion(t *testing.T) {
+	type Sample struct {
+		Verbose bool `name:"verbose,v"`
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// `-v` is the alias of the version flag of github.com/urfave/cli.
+	assert.Panics(t, func() { runApp(flags, "-v") })
+
+	var (
+		context *cli.Context
+		app     = cli.NewApp()
+	)
+
+	app.Writer = ioutil.Discard
+	app.HideVersion = true
+	app.Flags = flags
+	app.Action = func(c *cli.Context) error {
+		context = c
+		return nil
+	}
+
+	err = app.Run([]string{"", "-v"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, &Sample{Verbose: true}, sample)
+}
+
+func TestFlagsFromStructDuplicateAliases(t *testing.T) {
+	sample := struct {
+		Verbose bool `name:"verbose,v"`
+		Version bool `name:"version,v"`
+	}{}
+
+	result, err := FlagsFromStruct(&sample)
+	assert.NotNil(t, err)
+	switch err.(type) {
+	case *ErrDuplicateFlagName:
+	default:
+		t.Error(err)
+		return
+	}
+	assert.EqualValues(t, ([]cli.Flag)(nil), result)
+}
+
+func TestFlagsToStructRequired(t *testing.T) {
+	type Sample struct {
+		Token string `required:"true"`
+		Host  string `required:"true" env:"CLISTRUCT_TEST_REQUIRED_HOST"`
+		User  string `required:"true" name:"user,u"`
+		DB    struct {
+			Name string `required:"true" value:"default"`
+		}
+	}
+
+	os.Setenv("CLISTRUCT_TEST_REQUIRED_HOST", "example.com")
+	defer os.Unsetenv("CLISTRUCT_TEST_REQUIRED_HOST")
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags, "-u", "root")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	assert.NotNil(t, err)
+	switch v := err.(type) {
+	case *ErrRequired:
+		assert.EqualValues(t, []string{"token", "db.name"}, v.Names())
+	default:
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, "example.com", sample.Host)
+	assert.EqualValues(t, "root", sample.User)
+}
+
+func TestFlagsToStructNamedTypes(t *testing.T) {
+	type (
+		port    int
+		mode    string
+		enabled bool
+		timeout time.Duration
+		ports   []port
+	)
+	type Sample struct {
+		Port    port    `value:"80"`
+		Mode    mode    `value:"json"`
+		Enabled enabled `usage:"hello"`
+		Timeout timeout `value:"5"`
+		Ports   ports
+		Modes   []mode
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.EqualValues(
+		t,
+		[]cli.Flag{
+			cli.IntFlag{Name: "port", Value: 80},
+			cli.StringFlag{Name: "mode", Value: "json"},
+			cli.BoolFlag{Name: "enabled", Usage: "hello"},
+			cli.Int64Flag{Name: "timeout", Value: int64(5)},
+			cli.IntSliceFlag{Name: "ports"},
+			cli.StringSliceFlag{Name: "modes"},
+		},
+		flags,
+	)
+
+	context, err := runApp(
+		flags,
+		"--port", "8080",
+		"--enabled",
+		"--ports", "1", "--ports", "2",
+		"--modes", "text",
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(
+		t,
+		&Sample{
+			Port:    8080,
+			Mode:    "json",
+			Enabled: true,
+			Timeout: 5,
+			Ports:   ports{1, 2},
+			Modes:   []mode{"text"},
+		},
+		sample,
+	)
+}
+
+func TestFlagsToStructGeneric(t *testing.T) {
+	type Sample struct {
+		Level   testLevel `value:"info"`
+		Verbose testLevel `value:"debug"`
+		Generic testGeneric
+		Nested  struct {
+			Level testLevel `value:"error"`
+		}
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	// Flags are bound to the copies of the fields,
+	// so the struct is written only when folding.
+	assert.EqualValues(t, testLevel(0), sample.Level)
+
+	context, err := runApp(
+		flags,
+		"--verbose", "error",
+		"--generic", "hello",
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	target := &Sample{}
+	err = FlagsToStruct(context, target)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	expectedSample := &Sample{
+		Level:   1,
+		Verbose: 2,
+		Generic: testGeneric{"hello"},
+	}
+	expectedSample.Nested.Level = 2
+
+	assert.EqualValues(t, &Sample{}, sample)
+	assert.EqualValues(t, expectedSample, target)
+}
+
+func TestFlagsFromStructGenericInvalidValue(t *testing.T) {
+	sample := struct {
+		Level testLevel `value:"verbose"`
+	}{}
+
+	result, err := FlagsFromStruct(&sample)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, ([]cli.Flag)(nil), result)
+}
@@ -0,0 +1,36 @@
+package clistruct
+
+import (
+	"reflect"
+)
+
+// Mapper maps the structs to the flags, commands and
+// positional arguments of github.com/urfave/cli and back.
+// Each mapper has its own options (naming strategy, tag names,
+// environment variables prefix, strictness, list delimiter)
+// and type registry, so mappers with different conventions
+// could be used at the same time.
+// Package level functions use the default Mapper.
+// It is safe to use Mapper concurrently.
+type Mapper struct {
+	options *options
+}
+
+// defaultMapper is the Mapper used by the package level functions.
+var defaultMapper = &Mapper{options: newOptions(types, nil)}
+
+// New creates a new Mapper with the options.
+// Type registry of the mapper is a copy of the default
+// Mapper registry, so it has the types registered with
+// RegisterType before New was called.
+func New(opts ...Option) *Mapper {
+	return &Mapper{options: newOptions(types.clone(), opts)}
+}
+
+// RegisterType registers a handler for the fields of type t
+// in the mapper, replacing the handler registered before, if any.
+// Other mappers are not affected.
+// It is safe to call RegisterType concurrently.
+func (m *Mapper) RegisterType(t reflect.Type, handler TypeHandler) {
+	m.options.types.register(t, handler)
+}
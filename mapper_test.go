@@ -0,0 +1,158 @@
+package clistruct
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+type testMapped struct {
+	MaxConns int               `value:"10"`
+	HTTPPort uint              `flag:"listen-port"`
+	Hosts    []uint            `value:"1;2"`
+	Labels   map[string]string `default:"a=1;b=2"`
+}
+
+func TestMapper(t *testing.T) {
+	var (
+		kebab = New(
+			WithNameMapper(KebabCase),
+			WithTagName("name", "flag"),
+			WithTagName("value", "default"),
+			WithListDelimiter(";"),
+			WithEnvPrefix("APP_"),
+		)
+		lower = New()
+		wg    sync.WaitGroup
+	)
+
+	for n := 0; n < 10; n++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+
+			flags, err := kebab.FlagsFromStruct(&testMapped{})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			names := []string{}
+			for _, flag := range flags {
+				names = append(names, flag.GetName())
+			}
+			assert.EqualValues(t, []string{"max-conns", "listen-port", "hosts", "labels"}, names)
+			assert.EqualValues(t, "APP_MAX_CONNS", flags[0].(cli.IntFlag).EnvVar)
+			// Value tag is not read by the mapper, default tag is.
+			assert.EqualValues(t, 0, flags[0].(cli.IntFlag).Value)
+		}()
+		go func() {
+			defer wg.Done()
+
+			flags, err := lower.FlagsFromStruct(&testMapped{Hosts: []uint{1}}, WithStructDefaults())
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			names := []string{}
+			for _, flag := range flags {
+				names = append(names, flag.GetName())
+			}
+			assert.EqualValues(t, []string{"maxconns", "httpport", "hosts", "labels"}, names)
+			assert.EqualValues(t, "", flags[0].(cli.IntFlag).EnvVar)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestMapperListDelimiter(t *testing.T) {
+	mapper := New(WithTagName("value", "default"), WithListDelimiter(";"))
+	sample := &testMapped{}
+
+	flags, err := mapper.FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags, "--hosts", "3;4", "--httpport", "80")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = mapper.FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, []uint{3, 4}, sample.Hosts)
+	assert.EqualValues(t, map[string]string{"a": "1", "b": "2"}, sample.Labels)
+	assert.EqualValues(t, 80, sample.HTTPPort)
+
+	sample2 := &struct {
+		Hosts []uint `delimiter:"|"`
+	}{}
+
+	flags, err = mapper.FlagsFromStruct(sample2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err = runApp(flags, "--hosts", "5|6")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = mapper.FlagsToStruct(context, sample2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, []uint{5, 6}, sample2.Hosts)
+}
+
+type testMapperSize int
+
+func TestMapperRegisterType(t *testing.T) {
+	var (
+		mapper = New()
+		t1     = reflect.TypeOf(testMapperSize(0))
+		parsed = func(field reflect.StructField, v string) (interface{}, error) {
+			return 42, nil
+		}
+	)
+
+	mapper.RegisterType(
+		t1,
+		TypeHandler{
+			Flag:   newFlag(cli.IntFlag{}),
+			Parse:  parsed,
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.Int(key), nil },
+			Format: formatValue,
+		},
+	)
+
+	sample := &struct {
+		Size testMapperSize `value:"1k"`
+	}{}
+
+	flags, err := mapper.FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.EqualValues(t, 42, flags[0].(cli.IntFlag).Value)
+
+	// Default mapper has no handler for the type,
+	// so the underlying int kind is used.
+	_, err = FlagsFromStruct(sample)
+	assert.IsType(t, &ErrParse{}, err)
+}
@@ -0,0 +1,79 @@
+package clistruct
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	kebabDelimiter = "-"
+	snakeDelimiter = "_"
+)
+
+// NameMapper maps the name of the struct field
+// to the flag name, for example `MaxIdleConns`
+// to `max-idle-conns`.
+type NameMapper func(name string) string
+
+// LowerCase maps `MaxIdleConns` to `maxidleconns`.
+func LowerCase(name string) string {
+	return strings.ToLower(name)
+}
+
+// KebabCase maps `MaxIdleConns` to `max-idle-conns`,
+// acronyms are kept together, so `HTTPPort` is mapped to `http-port`.
+func KebabCase(name string) string {
+	return strings.ToLower(strings.Join(splitName(name), kebabDelimiter))
+}
+
+// SnakeCase maps `MaxIdleConns` to `max_idle_conns`,
+// acronyms are kept together, so `HTTPPort` is mapped to `http_port`.
+func SnakeCase(name string) string {
+	return strings.ToLower(strings.Join(splitName(name), snakeDelimiter))
+}
+
+// splitName splits the name into words by the case changes.
+// Word starts with the upper case letter following the lower case
+// letter or digit, or with the last upper case letter of the
+// acronym followed by the lower case letter, so `HTTPPort` is split
+// into `HTTP` and `Port`. Single upper case letter is not treated
+// as an acronym, so `IPv6Addr` is split into `IPv6` and `Addr`,
+// `OAuth2Token` into `OAuth2` and `Token` (and `ATest` is kept whole).
+// Other characters separate the words.
+func splitName(name string) []string {
+	var (
+		runes = []rune(name)
+		words []string
+		start = -1
+	)
+
+	for n, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:n]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = n
+			continue
+		}
+
+		if unicode.IsUpper(r) {
+			prev := runes[n-1]
+			next := n+1 < len(runes) && unicode.IsLower(runes[n+1])
+			acronym := n-2 >= start && unicode.IsUpper(runes[n-2])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && acronym && next) {
+				words = append(words, string(runes[start:n]))
+				start = n
+			}
+		}
+	}
+
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
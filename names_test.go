@@ -0,0 +1,132 @@
+package clistruct
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func TestNameMappers(t *testing.T) {
+	for _, c := range []struct {
+		name  string
+		lower string
+		kebab string
+		snake string
+	}{
+		{"Port", "port", "port", "port"},
+		{"MaxIdleConns", "maxidleconns", "max-idle-conns", "max_idle_conns"},
+		{"HTTPPort", "httpport", "http-port", "http_port"},
+		{"UserID", "userid", "user-id", "user_id"},
+		{"TLS", "tls", "tls", "tls"},
+		{"Retry3Times", "retry3times", "retry3-times", "retry3_times"},
+		{"Max_Conns", "max_conns", "max-conns", "max_conns"},
+		{"IPv6Addr", "ipv6addr", "ipv6-addr", "ipv6_addr"},
+		{"OAuth2Token", "oauth2token", "oauth2-token", "oauth2_token"},
+		{"XMLHttpRequest", "xmlhttprequest", "xml-http-request", "xml_http_request"},
+	} {
+		assert.EqualValues(t, c.lower, LowerCase(c.name), c.name)
+		assert.EqualValues(t, c.kebab, KebabCase(c.name), c.name)
+		assert.EqualValues(t, c.snake, SnakeCase(c.name), c.name)
+	}
+}
+
+type testNamed struct {
+	MaxIdleConns int    `value:"2"`
+	HTTPPort     int    `value:"80"`
+	LogLevel     string `name:"loglevel"`
+	DBPool       struct {
+		MaxConns int
+	}
+}
+
+func TestFlagsFromStructNameMapper(t *testing.T) {
+	for _, c := range []struct {
+		mapper NameMapper
+		names  []string
+		envs   []string
+	}{
+		{
+			LowerCase,
+			[]string{"maxidleconns", "httpport", "loglevel", "dbpool.maxconns"},
+			[]string{"MAXIDLECONNS", "HTTPPORT", "LOGLEVEL", "DBPOOL_MAXCONNS"},
+		},
+		{
+			KebabCase,
+			[]string{"max-idle-conns", "http-port", "loglevel", "db-pool.max-conns"},
+			[]string{"MAX_IDLE_CONNS", "HTTP_PORT", "LOGLEVEL", "DB_POOL_MAX_CONNS"},
+		},
+		{
+			SnakeCase,
+			[]string{"max_idle_conns", "http_port", "loglevel", "db_pool.max_conns"},
+			[]string{"MAX_IDLE_CONNS", "HTTP_PORT", "LOGLEVEL", "DB_POOL_MAX_CONNS"},
+		},
+		{
+			func(name string) string { return "x" + strings.ToLower(name) },
+			[]string{"xmaxidleconns", "xhttpport", "loglevel", "xdbpool.xmaxconns"},
+			[]string{"XMAXIDLECONNS", "XHTTPPORT", "LOGLEVEL", "XDBPOOL_XMAXCONNS"},
+		},
+	} {
+		flags, err := FlagsFromStruct(
+			&testNamed{},
+			WithNameMapper(c.mapper),
+			WithAutoEnv(),
+		)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		names := []string{}
+		envs := []string{}
+		for _, flag := range flags {
+			names = append(names, flag.GetName())
+			switch f := flag.(type) {
+			case cli.IntFlag:
+				envs = append(envs, f.EnvVar)
+			case cli.StringFlag:
+				envs = append(envs, f.EnvVar)
+			}
+		}
+
+		assert.EqualValues(t, c.names, names)
+		assert.EqualValues(t, c.envs, envs)
+	}
+}
+
+func TestFlagsToStructNameMapper(t *testing.T) {
+	path := writeConfig(t, "config.yaml", "http-port: 8080\ndb-pool: {max-conns: 10}\n")
+	defer os.RemoveAll(filepath.Dir(path))
+
+	opts := []Option{WithNameMapper(KebabCase), WithConfig(path), WithAutoEnv()}
+	sample := &testNamed{}
+
+	flags, err := FlagsFromStruct(sample, opts...)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	os.Setenv("MAX_IDLE_CONNS", "5")
+	defer os.Unsetenv("MAX_IDLE_CONNS")
+
+	context, err := runApp(flags, "--loglevel", "debug")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample, opts...)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, 5, sample.MaxIdleConns)
+	assert.EqualValues(t, 8080, sample.HTTPPort)
+	assert.EqualValues(t, "debug", sample.LogLevel)
+	assert.EqualValues(t, 10, sample.DBPool.MaxConns)
+}
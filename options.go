@@ -0,0 +1,156 @@
+package clistruct
+
+// Option configures the way structs are mapped to the flags.
+type Option func(*options)
+
+type options struct {
+	envAuto    bool
+	envPrefix  string
+	config     bool
+	configPath string
+	onlySet    bool
+
+	structDefaults bool
+	strict         bool
+	nameMapper     NameMapper
+	tags           map[string]string
+	listDelimiter  string
+	types          *registry
+}
+
+func newOptions(types *registry, opts []Option) *options {
+	o := &options{
+		nameMapper:    LowerCase,
+		listDelimiter: listDelimiter,
+		types:         types,
+	}
+
+	return o.with(opts)
+}
+
+// with returns a copy of the options with opts applied.
+func (o *options) with(opts []Option) *options {
+	c := *o
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return &c
+}
+
+// WithAutoEnv enables environment variables for the flags
+// which have no env tag. Variable name is derived from the
+// flag name, so `--db.host` flag is bound to `DB_HOST`.
+func WithAutoEnv() Option {
+	return func(o *options) {
+		o.envAuto = true
+	}
+}
+
+// WithEnvPrefix enables environment variables for the flags
+// which have no env tag, just like WithAutoEnv, but each
+// variable name is prefixed with prefix, so with `MYAPP_`
+// prefix `--db.host` flag is bound to `MYAPP_DB_HOST`.
+func WithEnvPrefix(prefix string) Option {
+	return func(o *options) {
+		o.envAuto = true
+		o.envPrefix = prefix
+	}
+}
+
+// WithConfig adds the `--config` flag which value is a path
+// to the config file loaded by FlagsToStruct before folding the flags,
+// so the values could be overridden with environment and flags.
+// Default path is used only if the file exists,
+// empty path means there is no config file by default.
+// FlagsToStruct should receive this option too.
+func WithConfig(path string) Option {
+	return func(o *options) {
+		o.config = true
+		o.configPath = path
+	}
+}
+
+// WithOnlySet makes FlagsToStruct write only the fields
+// which flags was set on the command line or with environment
+// variables (and positional arguments which was passed),
+// so the values the struct already has are not overwritten
+// with the defaults.
+func WithOnlySet() Option {
+	return func(o *options) {
+		o.onlySet = true
+	}
+}
+
+// WithStructDefaults makes FlagsFromStruct use the values
+// the struct fields already have as the flag defaults,
+// so `&Flags{Port: 8080}` gives `--port` flag with default 8080.
+// Zero values are ignored, so value tag is used for them.
+func WithStructDefaults() Option {
+	return func(o *options) {
+		o.structDefaults = true
+	}
+}
+
+// WithStrict makes FlagsFromStruct return ErrUnsupportedType
+// for the fields of types which could not be mapped to the flags
+// (instead of mapping them to the generic flags without value)
+// and ErrUnexportedField for the unexported fields with mapping tags.
+// Fields which should not be mapped could be tagged with `cli:"-"`.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithNameMapper sets the mapper of the field names
+// to the flag names (and prefixes of the nested structs),
+// environment variables and config keys are derived from
+// the flag names, so they follow the mapper too.
+// Default mapper is LowerCase, KebabCase or SnakeCase could be
+// used instead, as well as any other function.
+// Names from name and prefix tags are used as is.
+func WithNameMapper(mapper NameMapper) Option {
+	return func(o *options) {
+		o.nameMapper = mapper
+	}
+}
+
+// WithTagName makes the mapper read the tag (like name, env or min)
+// from the struct tag with another name, so with
+// WithTagName("name", "flag") option flag names are taken
+// from `flag:"..."` tags and name tags are ignored.
+func WithTagName(tag string, name string) Option {
+	return func(o *options) {
+		tags := make(map[string]string, len(o.tags)+1)
+		for k, v := range o.tags {
+			tags[k] = v
+		}
+		tags[tag] = name
+
+		o.tags = tags
+	}
+}
+
+// WithListDelimiter sets the delimiter of the list values
+// parsed by the mapper: values of the slice and map flags
+// (except the slice flags of github.com/urfave/cli, which take
+// one value at a time) and the defaults from the value tags.
+// Delimiter tag of the field wins over it.
+func WithListDelimiter(delimiter string) Option {
+	return func(o *options) {
+		o.listDelimiter = delimiter
+	}
+}
+
+// forCommand returns options for the flags of the command
+// with the name, so automatic environment variables of
+// the command flags are prefixed with the command name.
+func (o *options) forCommand(name string) *options {
+	c := *o
+	if c.envAuto {
+		c.envPrefix = envNameFromFlagName(c.envPrefix, name) + envDelimiter
+	}
+
+	return &c
+}
@@ -0,0 +1,752 @@
+package clistruct
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	boolTypeTag        = "bool"
+	boolTTypeTag       = "boolt"
+	float64TypeTag     = "float64"
+	intTypeTag         = "int"
+	int64TypeTag       = "int64"
+	intSliceTypeTag    = "intslice"
+	int64SliceTypeTag  = "int64slice"
+	stringTypeTag      = "string"
+	stringSliceTypeTag = "stringslice"
+	uintTypeTag        = "uint"
+	uint64TypeTag      = "uint64"
+	durationTypeTag    = "duration"
+	genericTypeTag     = "generic"
+
+	uintSliceTypeTag     = "uintslice"
+	uint64SliceTypeTag   = "uint64slice"
+	float64SliceTypeTag  = "float64slice"
+	boolSliceTypeTag     = "boolslice"
+	durationSliceTypeTag = "durationslice"
+)
+
+var (
+	boolType        = reflect.TypeOf(*new(bool))
+	uintType        = reflect.TypeOf(*new(uint))
+	uint64Type      = reflect.TypeOf(*new(uint64))
+	intType         = reflect.TypeOf(*new(int))
+	int64Type       = reflect.TypeOf(*new(int64))
+	float64Type     = reflect.TypeOf(*new(float64))
+	intSliceType    = reflect.TypeOf(*new([]int))
+	int64SliceType  = reflect.TypeOf(*new([]int64))
+	stringType      = reflect.TypeOf(*new(string))
+	stringSliceType = reflect.TypeOf(*new([]string))
+	durationType    = reflect.TypeOf(*new(time.Duration))
+
+	uintSliceType     = reflect.TypeOf(*new([]uint))
+	uint64SliceType   = reflect.TypeOf(*new([]uint64))
+	float64SliceType  = reflect.TypeOf(*new([]float64))
+	boolSliceType     = reflect.TypeOf(*new([]bool))
+	durationSliceType = reflect.TypeOf(*new([]time.Duration))
+
+	timeType      = reflect.TypeOf(*new(time.Time))
+	timeSliceType = reflect.TypeOf(*new([]time.Time))
+	locationType  = reflect.TypeOf(*new(*time.Location))
+
+	ipType       = reflect.TypeOf(*new(net.IP))
+	ipNetType    = reflect.TypeOf(*new(*net.IPNet))
+	addrType     = reflect.TypeOf(*new(netip.Addr))
+	prefixType   = reflect.TypeOf(*new(netip.Prefix))
+	addrPortType = reflect.TypeOf(*new(netip.AddrPort))
+	urlType      = reflect.TypeOf(*new(*url.URL))
+)
+
+// TypeHandler describes how the fields of some type
+// are mapped to the flags and back.
+type TypeHandler struct {
+	// Flag returns a pointer to a new flag for the field.
+	// Name, Usage and EnvVar of the flag are filled by the mapper,
+	// Value is filled by the mapper only if field has a default value.
+	Flag func(field reflect.StructField) cli.Flag
+
+	// Parse parses a default value string from the value tag into
+	// the value which could be assigned to the Value of the flag.
+	// Nil Parse means the flag could not have a default value.
+	Parse func(field reflect.StructField, value string) (interface{}, error)
+
+	// Get reads the value of the flag with the name from context.
+	// Result should be convertible to the field type,
+	// nil result leaves the field untouched.
+	Get func(context *cli.Context, name string) (interface{}, error)
+
+	// Format formats the field value for display, it is also
+	// used to parse the defaults taken from the struct with
+	// WithStructDefaults, nil Format means such defaults are ignored.
+	Format func(value interface{}) string
+}
+
+// registry is a concurrency safe collection of type handlers.
+type registry struct {
+	lock     sync.RWMutex
+	handlers map[reflect.Type]TypeHandler
+}
+
+func newRegistry() *registry {
+	return &registry{
+		handlers: map[reflect.Type]TypeHandler{},
+	}
+}
+
+func (r *registry) register(t reflect.Type, handler TypeHandler) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.handlers[t] = handler
+}
+
+func (r *registry) lookup(t reflect.Type) (TypeHandler, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	handler, ok := r.handlers[t]
+	return handler, ok
+}
+
+// clone returns a new registry with the same handlers,
+// so handlers could be registered without affecting r.
+func (r *registry) clone() *registry {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	c := newRegistry()
+	for t, handler := range r.handlers {
+		c.handlers[t] = handler
+	}
+
+	return c
+}
+
+// RegisterType registers a handler for the fields of type t
+// in the default Mapper, replacing the handler registered before, if any.
+// Mappers created with New after that inherit the handler.
+// It is safe to call RegisterType concurrently.
+func RegisterType(t reflect.Type, handler TypeHandler) {
+	defaultMapper.RegisterType(t, handler)
+}
+
+var (
+	typeTagToHandler = map[string]TypeHandler{
+		boolTypeTag: {
+			Flag:   newFlag(cli.BoolFlag{}),
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.Bool(key), nil },
+			Format: formatValue,
+		},
+		boolTTypeTag: {
+			Flag:   newFlag(cli.BoolTFlag{}),
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.BoolT(key), nil },
+			Format: formatValue,
+		},
+		uintTypeTag: {
+			Flag:   newFlag(cli.UintFlag{}),
+			Parse:  parseUint,
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.Uint(key), nil },
+			Format: formatValue,
+		},
+		uint64TypeTag: {
+			Flag:   newFlag(cli.Uint64Flag{}),
+			Parse:  parseUint64,
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.Uint64(key), nil },
+			Format: formatValue,
+		},
+		intTypeTag: {
+			Flag:   newFlag(cli.IntFlag{}),
+			Parse:  parseInt,
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.Int(key), nil },
+			Format: formatValue,
+		},
+		int64TypeTag: {
+			Flag:   newFlag(cli.Int64Flag{}),
+			Parse:  parseInt64,
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.Int64(key), nil },
+			Format: formatValue,
+		},
+		float64TypeTag: {
+			Flag:   newFlag(cli.Float64Flag{}),
+			Parse:  parseFloat64,
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.Float64(key), nil },
+			Format: formatValue,
+		},
+		intSliceTypeTag: {
+			Flag:   newFlag(cli.IntSliceFlag{}),
+			Parse:  parseIntSlice,
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.IntSlice(key), nil },
+			Format: formatValue,
+		},
+		int64SliceTypeTag: {
+			Flag:   newFlag(cli.Int64SliceFlag{}),
+			Parse:  parseInt64Slice,
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.Int64Slice(key), nil },
+			Format: formatValue,
+		},
+		stringTypeTag: {
+			Flag:   newFlag(cli.StringFlag{}),
+			Parse:  parseString,
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.String(key), nil },
+			Format: formatValue,
+		},
+		stringSliceTypeTag: {
+			Flag:   newFlag(cli.StringSliceFlag{}),
+			Parse:  parseStringSlice,
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.StringSlice(key), nil },
+			Format: formatValue,
+		},
+		durationTypeTag: {
+			Flag:   newFlag(cli.DurationFlag{}),
+			Parse:  parseDuration,
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.Duration(key), nil },
+			Format: formatValue,
+		},
+		genericTypeTag: {
+			Flag:   newFlag(cli.GenericFlag{}),
+			Get:    func(ctx *cli.Context, key string) (interface{}, error) { return ctx.Generic(key), nil },
+			Format: formatValue,
+		},
+
+		uintSliceTypeTag:     newSliceHandler(uintType, parseUint, formatScalar),
+		uint64SliceTypeTag:   newSliceHandler(uint64Type, parseUint64, formatScalar),
+		float64SliceTypeTag:  newSliceHandler(float64Type, parseFloat64, formatScalar),
+		boolSliceTypeTag:     newSliceHandler(boolType, parseBool, formatScalar),
+		durationSliceTypeTag: newSliceHandler(durationType, parseDuration, formatScalar),
+	}
+
+	// kindToType maps a kind of the named types
+	// to the type which is used to lookup the handler.
+	// Narrow numeric kinds are mapped to the wider
+	// types and range checked when folding.
+	kindToType = map[reflect.Kind]reflect.Type{
+		reflect.Bool:    boolType,
+		reflect.Uint:    uintType,
+		reflect.Uint8:   uintType,
+		reflect.Uint16:  uintType,
+		reflect.Uint32:  uintType,
+		reflect.Uint64:  uint64Type,
+		reflect.Int:     intType,
+		reflect.Int8:    intType,
+		reflect.Int16:   intType,
+		reflect.Int32:   intType,
+		reflect.Int64:   int64Type,
+		reflect.Float32: float64Type,
+		reflect.Float64: float64Type,
+		reflect.String:  stringType,
+	}
+
+	// sliceKindToType maps a kind of the slice elements
+	// to the type which is used to lookup the handler.
+	// Slices of bytes are not mapped.
+	sliceKindToType = map[reflect.Kind]reflect.Type{
+		reflect.Bool:    boolSliceType,
+		reflect.Uint:    uintSliceType,
+		reflect.Uint16:  uintSliceType,
+		reflect.Uint32:  uintSliceType,
+		reflect.Uint64:  uint64SliceType,
+		reflect.Int:     intSliceType,
+		reflect.Int8:    intSliceType,
+		reflect.Int16:   intSliceType,
+		reflect.Int32:   intSliceType,
+		reflect.Int64:   int64SliceType,
+		reflect.Float32: float64SliceType,
+		reflect.Float64: float64SliceType,
+		reflect.String:  stringSliceType,
+	}
+
+	// types is a registry of the handlers for the field types
+	// used by the default Mapper, it is prepopulated with the built-in types.
+	types = newRegistry()
+
+	// builtinTypes are the built-in types
+	// which has no type tag.
+	builtinTypes = map[reflect.Type]TypeHandler{
+		timeType:      newScalarHandler(parseTime, formatTime),
+		timeSliceType: newSliceHandler(timeType, parseTime, formatTime),
+		locationType:  newScalarHandler(parseLocation, formatLocation),
+
+		ipType:                        newScalarHandler(parseIP, formatScalar),
+		reflect.SliceOf(ipType):       newSliceHandler(ipType, parseIP, formatScalar),
+		ipNetType:                     newScalarHandler(parseIPNet, formatScalar),
+		reflect.SliceOf(ipNetType):    newSliceHandler(ipNetType, parseIPNet, formatScalar),
+		addrType:                      newScalarHandler(parseAddr, formatScalar),
+		reflect.SliceOf(addrType):     newSliceHandler(addrType, parseAddr, formatScalar),
+		prefixType:                    newScalarHandler(parsePrefix, formatScalar),
+		reflect.SliceOf(prefixType):   newSliceHandler(prefixType, parsePrefix, formatScalar),
+		addrPortType:                  newScalarHandler(parseAddrPort, formatScalar),
+		reflect.SliceOf(addrPortType): newSliceHandler(addrPortType, parseAddrPort, formatScalar),
+		urlType:                       newScalarHandler(parseURL, formatScalar),
+		reflect.SliceOf(urlType):      newSliceHandler(urlType, parseURL, formatScalar),
+	}
+)
+
+func init() {
+	for t, tag := range map[reflect.Type]string{
+		boolType:        boolTypeTag,
+		uintType:        uintTypeTag,
+		uint64Type:      uint64TypeTag,
+		intType:         intTypeTag,
+		int64Type:       int64TypeTag,
+		float64Type:     float64TypeTag,
+		intSliceType:    intSliceTypeTag,
+		int64SliceType:  int64SliceTypeTag,
+		stringType:      stringTypeTag,
+		stringSliceType: stringSliceTypeTag,
+		durationType:    durationTypeTag,
+
+		uintSliceType:     uintSliceTypeTag,
+		uint64SliceType:   uint64SliceTypeTag,
+		float64SliceType:  float64SliceTypeTag,
+		boolSliceType:     boolSliceTypeTag,
+		durationSliceType: durationSliceTypeTag,
+	} {
+		types.register(t, typeTagToHandler[tag])
+	}
+	for t, handler := range builtinTypes {
+		types.register(t, handler)
+	}
+}
+
+// handlerFromType returns a handler for the values of type t.
+// Named types (like `type Port int`) which has no handler
+// registered are resolved with their underlying kind
+// (element kind for slices), pointers which has no handler
+// registered are resolved with the type they point to.
+func (r *registry) handlerFromType(t reflect.Type) (TypeHandler, bool) {
+	handler, ok := r.lookup(t)
+	if ok {
+		return handler, true
+	}
+
+	if t.Kind() == reflect.Ptr {
+		return r.handlerFromType(t.Elem())
+	}
+
+	if t.Kind() == reflect.Map {
+		if !r.isParseableType(t.Key()) || !r.isParseableType(t.Elem()) {
+			return TypeHandler{}, false
+		}
+
+		return newMapHandler(t, r), true
+	}
+
+	if t.Kind() == reflect.Slice {
+		t = sliceKindToType[t.Elem().Kind()]
+	} else {
+		t = kindToType[t.Kind()]
+	}
+	if t == nil {
+		return TypeHandler{}, false
+	}
+
+	return r.lookup(t)
+}
+
+// isParseableType reports whether values of type t
+// could be parsed with valueFromString.
+func (r *registry) isParseableType(t reflect.Type) bool {
+	if t.Kind() == reflect.Map {
+		return false
+	}
+	if isGenericType(t) {
+		return true
+	}
+
+	_, ok := r.handlerFromType(t)
+	return ok
+}
+
+// valueFromString parses s into the value of type t
+// with the same handlers which are used for the flags.
+func (r *registry) valueFromString(field reflect.StructField, t reflect.Type, s string) (reflect.Value, error) {
+	var (
+		handler TypeHandler
+		value   interface{}
+		ok      bool
+		err     error
+	)
+
+	_, ok = r.lookup(t)
+	if !ok && t.Kind() == reflect.Ptr {
+		elem, err := r.valueFromString(field, t.Elem(), s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		result := reflect.New(t.Elem())
+		result.Elem().Set(elem)
+		return result, nil
+	}
+	if !ok && isGenericType(t) {
+		result := reflect.New(t).Elem()
+		return result, newGenericValue(result).Set(s)
+	}
+
+	handler, ok = r.handlerFromType(t)
+	switch {
+	case !ok:
+		return reflect.Value{}, NewErrTypeMistmatch(t.String(), stringType.String())
+	case handler.Parse == nil && t.Kind() == reflect.Bool:
+		value, err = parseBool(field, s)
+	case handler.Parse == nil:
+		return reflect.Value{}, NewErrTypeMistmatch(t.String(), stringType.String())
+	default:
+		value, err = handler.Parse(field, s)
+	}
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	if getter, ok := value.(flag.Getter); ok {
+		value = getter.Get()
+	}
+
+	return convertValue(reflect.ValueOf(value), t)
+}
+
+// handlerFromStructField returns a handler for the field,
+// type tag always wins over the field type.
+// Fields without handler are mapped to generic flags.
+func handlerFromStructField(field reflect.StructField, o *options) TypeHandler {
+	handler, ok := typeTagToHandler[getStructFieldTag(field, typeTag)]
+	if ok {
+		return handler
+	}
+
+	handler, ok = o.types.handlerFromType(field.Type)
+	if ok {
+		return handler
+	}
+
+	return typeTagToHandler[genericTypeTag]
+}
+
+// newFlag returns a function which creates
+// new zero flags of the same type as prototype.
+func newFlag(prototype cli.Flag) func(reflect.StructField) cli.Flag {
+	t := indirectType(reflect.TypeOf(prototype))
+
+	return func(reflect.StructField) cli.Flag {
+		return reflect.New(t).Interface().(cli.Flag)
+	}
+}
+
+// newScalarHandler returns a handler for the values
+// which are parsed with parse and formatted with format.
+// Flags are cli.GenericFlag with scalarValue.
+func newScalarHandler(parse parser, format formatter) TypeHandler {
+	return TypeHandler{
+		Flag: func(field reflect.StructField) cli.Flag {
+			return &cli.GenericFlag{
+				Value: newScalarValue(field, parse, format),
+			}
+		},
+		Parse: func(field reflect.StructField, v string) (interface{}, error) {
+			value := newScalarValue(field, parse, format)
+			return value, value.Set(v)
+		},
+		Get: getterValueFromContext,
+		Format: func(v interface{}) string {
+			return format(reflect.StructField{}, v)
+		},
+	}
+}
+
+// newSliceHandler returns a handler for the slices
+// of elem type which elements are parsed with parse
+// and formatted with format.
+// Flags are cli.GenericFlag with sliceValue.
+func newSliceHandler(elem reflect.Type, parse parser, format formatter) TypeHandler {
+	t := reflect.SliceOf(elem)
+
+	return TypeHandler{
+		Flag: func(field reflect.StructField) cli.Flag {
+			return &cli.GenericFlag{
+				Value: newSliceValue(field, t, parse, format),
+			}
+		},
+		Parse: func(field reflect.StructField, v string) (interface{}, error) {
+			value := newSliceValue(field, t, parse, format)
+			err := value.Set(v)
+			if err != nil {
+				return nil, err
+			}
+
+			// Default values are replaced by the
+			// first value set by the user.
+			value.set = false
+
+			return value, nil
+		},
+		Get: getterValueFromContext,
+		Format: func(v interface{}) string {
+			return formatSlice(reflect.StructField{}, v, format)
+		},
+	}
+}
+
+// newMapHandler returns a handler for the maps of type t
+// which are parsed from the key=value pairs,
+// keys and values are parsed with the handlers from r.
+// Flags are cli.GenericFlag with mapValue.
+func newMapHandler(t reflect.Type, r *registry) TypeHandler {
+	return TypeHandler{
+		Flag: func(field reflect.StructField) cli.Flag {
+			return &cli.GenericFlag{
+				Value: newMapValue(field, t, r),
+			}
+		},
+		Parse: func(field reflect.StructField, v string) (interface{}, error) {
+			value := newMapValue(field, t, r)
+			err := value.Set(v)
+			if err != nil {
+				return nil, err
+			}
+
+			// Default values are replaced by the
+			// first value set by the user.
+			value.set = false
+
+			return value, nil
+		},
+		Get: getterValueFromContext,
+		Format: func(v interface{}) string {
+			return formatMap(reflect.StructField{}, v)
+		},
+	}
+}
+
+// getterValueFromContext returns a value of the generic flag
+// which implements flag.Getter.
+func getterValueFromContext(context *cli.Context, name string) (interface{}, error) {
+	getter, ok := context.Generic(name).(flag.Getter)
+	if !ok {
+		return nil, nil
+	}
+
+	return getter.Get(), nil
+}
+
+func formatValue(v interface{}) string {
+	if reflect.ValueOf(v).Kind() != reflect.Slice {
+		return formatScalar(reflect.StructField{}, v)
+	}
+
+	return formatSlice(reflect.StructField{}, v, formatScalar)
+}
+
+// formatSlice formats the elements of slice v
+// with format joining them with list delimiter of the field.
+func formatSlice(field reflect.StructField, v interface{}, format formatter) string {
+	var (
+		reflectValue = reflect.ValueOf(v)
+		values       = make([]string, reflectValue.Len())
+	)
+
+	for n := range values {
+		values[n] = format(field, reflectValue.Index(n).Interface())
+	}
+
+	return strings.Join(values, listDelimiterFromStructField(field))
+}
+
+// formatMap formats a map as a list
+// of key=value pairs sorted by key.
+func formatMap(field reflect.StructField, v interface{}) string {
+	var (
+		reflectValue = reflect.ValueOf(v)
+		pairs        = make([]string, 0, reflectValue.Len())
+	)
+
+	for _, key := range reflectValue.MapKeys() {
+		pairs = append(
+			pairs,
+			formatValue(key.Interface())+
+				mapPairDelimiter+
+				formatValue(reflectValue.MapIndex(key).Interface()),
+		)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, listDelimiterFromStructField(field))
+}
+
+// formatter formats the value of the field as a string.
+type formatter func(field reflect.StructField, v interface{}) string
+
+func formatScalar(field reflect.StructField, v interface{}) string {
+	return fmt.Sprint(v)
+}
+
+func formatTime(field reflect.StructField, v interface{}) string {
+	return v.(time.Time).Format(timeLayoutFromStructField(field))
+}
+
+func formatLocation(field reflect.StructField, v interface{}) string {
+	return v.(*time.Location).String()
+}
+
+// parser parses a string representation of the field value.
+type parser func(field reflect.StructField, v string) (interface{}, error)
+
+func parseBool(field reflect.StructField, v string) (interface{}, error) {
+	return strconv.ParseBool(v)
+}
+
+func parseUint(field reflect.StructField, v string) (interface{}, error) {
+	u, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	return uint(u), nil
+}
+
+func parseUint64(field reflect.StructField, v string) (interface{}, error) {
+	return strconv.ParseUint(v, 10, 64)
+}
+
+func parseInt(field reflect.StructField, v string) (interface{}, error) {
+	i, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	return int(i), nil
+}
+
+func parseInt64(field reflect.StructField, v string) (interface{}, error) {
+	return strconv.ParseInt(v, 10, 64)
+}
+
+func parseFloat64(field reflect.StructField, v string) (interface{}, error) {
+	return strconv.ParseFloat(v, 64)
+}
+
+func parseIntSlice(field reflect.StructField, v string) (interface{}, error) {
+	var (
+		ints     = strings.Split(v, listDelimiterFromStructField(field))
+		intSlice = make(cli.IntSlice, len(ints))
+		i        int64
+		err      error
+	)
+
+	for k, v := range ints {
+		i, err = strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		intSlice[k] = int(i)
+	}
+
+	return &intSlice, nil
+}
+
+func parseInt64Slice(field reflect.StructField, v string) (interface{}, error) {
+	var (
+		ints       = strings.Split(v, listDelimiterFromStructField(field))
+		int64Slice = make(cli.Int64Slice, len(ints))
+		i          int64
+		err        error
+	)
+
+	for k, v := range ints {
+		i, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		int64Slice[k] = i
+	}
+
+	return &int64Slice, nil
+}
+
+func parseString(field reflect.StructField, v string) (interface{}, error) {
+	return v, nil
+}
+
+func parseStringSlice(field reflect.StructField, v string) (interface{}, error) {
+	stringSlice := cli.StringSlice(strings.Split(v, listDelimiterFromStructField(field)))
+	return &stringSlice, nil
+}
+
+func parseDuration(field reflect.StructField, v string) (interface{}, error) {
+	return time.ParseDuration(v)
+}
+
+func parseTime(field reflect.StructField, v string) (interface{}, error) {
+	return time.Parse(timeLayoutFromStructField(field), v)
+}
+
+func parseLocation(field reflect.StructField, v string) (interface{}, error) {
+	return time.LoadLocation(v)
+}
+
+func parseIP(field reflect.StructField, v string) (interface{}, error) {
+	ip := net.ParseIP(v)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", v)
+	}
+
+	return ip, nil
+}
+
+func parseIPNet(field reflect.StructField, v string) (interface{}, error) {
+	_, ipNet, err := net.ParseCIDR(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return ipNet, nil
+}
+
+func parseAddr(field reflect.StructField, v string) (interface{}, error) {
+	return netip.ParseAddr(v)
+}
+
+func parsePrefix(field reflect.StructField, v string) (interface{}, error) {
+	return netip.ParsePrefix(v)
+}
+
+func parseAddrPort(field reflect.StructField, v string) (interface{}, error) {
+	return netip.ParseAddrPort(v)
+}
+
+func parseURL(field reflect.StructField, v string) (interface{}, error) {
+	return url.Parse(v)
+}
+
+// timeLayoutFromStructField returns a layout of the time
+// values for the field, RFC3339 is used if field
+// has no layout tag.
+func timeLayoutFromStructField(field reflect.StructField) string {
+	layout := getStructFieldTag(field, layoutTag)
+	if layout == "" {
+		return time.RFC3339
+	}
+
+	return layout
+}
+
+// listDelimiterFromStructField returns a delimiter of the
+// list values for the field, comma is used if field
+// has no delimiter tag.
+func listDelimiterFromStructField(field reflect.StructField) string {
+	delimiter := field.Tag.Get(delimiterTag)
+	if delimiter == "" {
+		return listDelimiter
+	}
+
+	return delimiter
+}
@@ -0,0 +1,689 @@
+package clistruct
+
+import (
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+type testByteSize uint64
+
+type testByteSizeValue struct {
+	size testByteSize
+}
+
+func (v *testByteSizeValue) Set(s string) error {
+	multiplier := uint64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1 << 20
+	}
+
+	n, err := strconv.ParseUint(strings.TrimRight(s, "KM"), 10, 64)
+	if err != nil {
+		return err
+	}
+	v.size = testByteSize(n * multiplier)
+
+	return nil
+}
+
+func (v *testByteSizeValue) String() string {
+	return strconv.FormatUint(uint64(v.size), 10)
+}
+
+var testByteSizeHandler = TypeHandler{
+	Flag: func(reflect.StructField) cli.Flag {
+		return &cli.GenericFlag{Value: &testByteSizeValue{}}
+	},
+	Parse: func(field reflect.StructField, v string) (interface{}, error) {
+		value := &testByteSizeValue{}
+		return value, value.Set(v)
+	},
+	Get: func(context *cli.Context, name string) (interface{}, error) {
+		return context.Generic(name).(*testByteSizeValue).size, nil
+	},
+	Format: func(v interface{}) string {
+		return strconv.FormatUint(uint64(v.(testByteSize)), 10)
+	},
+}
+
+// restoreTypes returns a function which restores the handlers
+// of the default registry, so types registered by the test
+// do not leak into other tests.
+func restoreTypes() func() {
+	saved := types.clone()
+
+	return func() {
+		types.lock.Lock()
+		defer types.lock.Unlock()
+
+		types.handlers = saved.handlers
+	}
+}
+
+func TestRegisterType(t *testing.T) {
+	defer restoreTypes()()
+
+	RegisterType(reflect.TypeOf(testByteSize(0)), testByteSizeHandler)
+
+	type Sample struct {
+		Buffer testByteSize `value:"4K"`
+		Limit  testByteSize
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.EqualValues(
+		t,
+		[]cli.Flag{
+			cli.GenericFlag{Name: "buffer", Value: &testByteSizeValue{4 << 10}},
+			cli.GenericFlag{Name: "limit", Value: &testByteSizeValue{}},
+		},
+		flags,
+	)
+
+	context, err := runApp(flags, "--limit", "2M")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, &Sample{Buffer: 4 << 10, Limit: 2 << 20}, sample)
+}
+
+func TestRegisterTypeWithoutFormat(t *testing.T) {
+	var (
+		mapper  = New()
+		handler = testByteSizeHandler
+	)
+
+	handler.Format = nil
+	mapper.RegisterType(reflect.TypeOf(testByteSize(0)), handler)
+
+	flags, err := mapper.FlagsFromStruct(
+		&struct{ Buffer testByteSize }{Buffer: 4 << 10},
+		WithStructDefaults(),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Struct default could not be formatted, so it is ignored.
+	assert.EqualValues(
+		t,
+		[]cli.Flag{cli.GenericFlag{Name: "buffer", Value: &testByteSizeValue{}}},
+		flags,
+	)
+}
+
+func TestRegisterTypeConcurrently(t *testing.T) {
+	var (
+		wg sync.WaitGroup
+		t1 = reflect.TypeOf(testByteSize(0))
+	)
+
+	defer restoreTypes()()
+
+	for n := 0; n < 10; n++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterType(t1, testByteSizeHandler)
+		}()
+		go func() {
+			defer wg.Done()
+			types.handlerFromType(t1)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestFlagsToStructNarrowTypes(t *testing.T) {
+	type Sample struct {
+		Int8          int8    `value:"-8"`
+		Int16         int16   `value:"16"`
+		Int32         int32   `value:"32"`
+		Uint8         uint8   `value:"8"`
+		Uint16        uint16  `value:"16"`
+		Uint32        uint32  `value:"32"`
+		Float32       float32 `value:"1.5"`
+		Int32Slice    []int32
+		UintSlice     []uint          `value:"1,2"`
+		Uint64Slice   []uint64        `value:"1"`
+		Float64Slice  []float64       `value:"1.5"`
+		Float32Slice  []float32       `value:"1.5"`
+		BoolSlice     []bool          `value:"true,false"`
+		DurationSlice []time.Duration `value:"1s"`
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(
+		flags,
+		"--int16", "-16",
+		"--uint32", "4294967295",
+		"--float32", "2.5",
+		"--int32slice", "1", "--int32slice", "2",
+		"--uint64slice", "18446744073709551615",
+		"--float32slice", "2.5,3.5",
+		"--durationslice", "1m",
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(
+		t,
+		&Sample{
+			Int8:          -8,
+			Int16:         -16,
+			Int32:         32,
+			Uint8:         8,
+			Uint16:        16,
+			Uint32:        4294967295,
+			Float32:       2.5,
+			Int32Slice:    []int32{1, 2},
+			UintSlice:     []uint{1, 2},
+			Uint64Slice:   []uint64{18446744073709551615},
+			Float64Slice:  []float64{1.5},
+			Float32Slice:  []float32{2.5, 3.5},
+			BoolSlice:     []bool{true, false},
+			DurationSlice: []time.Duration{time.Minute},
+		},
+		sample,
+	)
+}
+
+func TestFlagsToStructOverflow(t *testing.T) {
+	type Sample struct {
+		Small int8
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags, "--small", "300")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	assert.NotNil(t, err)
+	switch v := err.(type) {
+	case *ErrOverflow:
+		assert.EqualValues(t, "Value '300' of field 'Small' overflows type 'int8'", v.Error())
+	default:
+		t.Error(err)
+	}
+}
+
+func TestFlagsFromStructDefaultOverflow(t *testing.T) {
+	sample := struct {
+		Small []uint16 `value:"1,65536"`
+	}{}
+
+	result, err := FlagsFromStruct(&sample)
+	assert.NotNil(t, err)
+	switch err.(type) {
+	case *ErrOverflow:
+	default:
+		t.Error(err)
+	}
+	assert.EqualValues(t, ([]cli.Flag)(nil), result)
+}
+
+func TestFlagsToStructTime(t *testing.T) {
+	type Sample struct {
+		Since    time.Time      `value:"2017-01-02T15:04:05Z"`
+		Until    time.Time      `layout:"2006-01-02"`
+		Dates    []time.Time    `layout:"2006-01-02" value:"2017-01-01,2017-02-01"`
+		Location *time.Location `value:"UTC"`
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(
+		flags,
+		"--until", "2017-03-04",
+		"--location", "Europe/Moscow",
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	location, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(
+		t,
+		&Sample{
+			Since: time.Date(2017, 1, 2, 15, 4, 5, 0, time.UTC),
+			Until: time.Date(2017, 3, 4, 0, 0, 0, 0, time.UTC),
+			Dates: []time.Time{
+				time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC),
+			},
+			Location: location,
+		},
+		sample,
+	)
+	assert.EqualValues(t, "2017-01-01,2017-02-01", flags[2].(cli.GenericFlag).Value.String())
+}
+
+func TestFlagsFromStructTimeInvalidValue(t *testing.T) {
+	sample := struct {
+		Since time.Time `layout:"2006-01-02" value:"2017-01-02T15:04:05Z"`
+	}{}
+
+	result, err := FlagsFromStruct(&sample)
+	assert.NotNil(t, err)
+	assert.EqualValues(t, ([]cli.Flag)(nil), result)
+}
+
+func TestFlagsToStructNetwork(t *testing.T) {
+	type Sample struct {
+		IP        net.IP         `value:"127.0.0.1"`
+		IPs       []net.IP       `value:"10.0.0.1,10.0.0.2"`
+		Network   *net.IPNet     `value:"10.0.0.0/8"`
+		Addr      netip.Addr     `value:"::1"`
+		Prefixes  []netip.Prefix `value:"192.168.0.0/16"`
+		Listen    netip.AddrPort `value:"0.0.0.0:80"`
+		Upstreams []netip.AddrPort
+		URL       *url.URL
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(
+		flags,
+		"--listen", "127.0.0.1:8080",
+		"--upstreams", "10.0.0.1:80", "--upstreams", "10.0.0.2:80",
+		"--url", "https://example.com/path",
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+	expectedURL, _ := url.Parse("https://example.com/path")
+
+	assert.EqualValues(
+		t,
+		&Sample{
+			IP:       net.ParseIP("127.0.0.1"),
+			IPs:      []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")},
+			Network:  network,
+			Addr:     netip.MustParseAddr("::1"),
+			Prefixes: []netip.Prefix{netip.MustParsePrefix("192.168.0.0/16")},
+			Listen:   netip.MustParseAddrPort("127.0.0.1:8080"),
+			Upstreams: []netip.AddrPort{
+				netip.MustParseAddrPort("10.0.0.1:80"),
+				netip.MustParseAddrPort("10.0.0.2:80"),
+			},
+			URL: expectedURL,
+		},
+		sample,
+	)
+}
+
+func TestFlagsFromStructNetworkInvalidValue(t *testing.T) {
+	sample := struct {
+		Listen netip.AddrPort `value:"localhost"`
+	}{}
+
+	result, err := FlagsFromStruct(&sample)
+	assert.NotNil(t, err)
+	switch err.(type) {
+	case *ErrParse:
+		assert.Contains(t, err.Error(), "'listen'")
+	default:
+		t.Error(err)
+	}
+	assert.EqualValues(t, ([]cli.Flag)(nil), result)
+
+	type Sample struct {
+		IP net.IP
+	}
+
+	flags, err := FlagsFromStruct(&Sample{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = runApp(flags, "--ip", "localhost")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "-ip")
+}
+
+func TestFlagsToStructMap(t *testing.T) {
+	type Sample struct {
+		Labels  map[string]string `value:"env=dev,team=core"`
+		Weights map[string]int
+		Ports   map[int]bool
+	}
+
+	sample := &Sample{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(
+		flags,
+		"--labels", "env=prod", "--labels", "owner=me",
+		"--weights", "a=1,b=2",
+		"--ports", "80=true",
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(
+		t,
+		&Sample{
+			Labels:  map[string]string{"env": "prod", "owner": "me"},
+			Weights: map[string]int{"a": 1, "b": 2},
+			Ports:   map[int]bool{80: true},
+		},
+		sample,
+	)
+}
+
+func TestFlagsToStructMapEnv(t *testing.T) {
+	type Sample struct {
+		Labels map[string]string `env:"CLISTRUCT_TEST_LABELS" value:"a=1"`
+	}
+
+	os.Setenv("CLISTRUCT_TEST_LABELS", "env=prod")
+	defer os.Unsetenv("CLISTRUCT_TEST_LABELS")
+
+	for _, c := range []struct {
+		args     []string
+		expected map[string]string
+	}{
+		{nil, map[string]string{"env": "prod"}},
+		{[]string{"--labels", "env=dev"}, map[string]string{"env": "dev"}},
+		{[]string{"--labels", "team=core"}, map[string]string{"team": "core"}},
+		{
+			[]string{"--labels", "env=dev", "--labels", "team=core"},
+			map[string]string{"env": "dev", "team": "core"},
+		},
+	} {
+		sample := &Sample{}
+
+		flags, err := FlagsFromStruct(sample)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		context, err := runApp(flags, c.args...)
+		if err != nil {
+			t.Error(c.args, err)
+			continue
+		}
+
+		err = FlagsToStruct(context, sample)
+		if err != nil {
+			t.Error(c.args, err)
+			continue
+		}
+
+		// Values set on the command line replace
+		// the environment variable value.
+		assert.EqualValues(t, c.expected, sample.Labels, "%v", c.args)
+	}
+}
+
+func TestFlagsToStructMapInvalid(t *testing.T) {
+	type Sample struct {
+		Labels map[string]string
+	}
+
+	flags, err := FlagsFromStruct(&Sample{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = runApp(flags, "--labels", "env=prod", "--labels", "env=dev")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Key 'env' is set more than once")
+
+	_, err = runApp(flags, "--labels", "env")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Expected 'key=value' pair, got 'env'")
+
+	flags, err = FlagsFromStruct(
+		&struct {
+			Limits map[string]int8
+		}{},
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	_, err = runApp(flags, "--limits", "cpu=300")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Value '300' of field 'Limits' overflows type 'int8'")
+
+	result, err := FlagsFromStruct(
+		&struct {
+			Labels map[string]int `value:"a=1,a=2"`
+		}{},
+	)
+	assert.NotNil(t, err)
+	switch err.(type) {
+	case *ErrParse:
+	default:
+		t.Error(err)
+	}
+	assert.EqualValues(t, ([]cli.Flag)(nil), result)
+}
+
+func TestFlagsToStructPointers(t *testing.T) {
+	type Sample struct {
+		Int      *int
+		Int8     *int8 `value:"8"`
+		Bool     *bool
+		Disabled *bool
+		String   *string
+		Duration *time.Duration
+		Time     *time.Time `layout:"2006-01-02"`
+		Level    *testLevel
+		Strings  *[]string
+		URL      *url.URL
+		Port     *int `arg:"0"`
+	}
+
+	for _, c := range []struct {
+		args   []string
+		check  func(*Sample)
+		sample *Sample
+	}{
+		{
+			args: nil,
+			check: func(sample *Sample) {
+				assert.Nil(t, sample.Int)
+				assert.EqualValues(t, 8, *sample.Int8)
+				assert.Nil(t, sample.Bool)
+				assert.Nil(t, sample.Disabled)
+				assert.Nil(t, sample.String)
+				assert.Nil(t, sample.Duration)
+				assert.Nil(t, sample.Time)
+				assert.Nil(t, sample.Level)
+				assert.Nil(t, sample.Strings)
+				assert.Nil(t, sample.URL)
+				assert.Nil(t, sample.Port)
+			},
+		},
+		{
+			args: []string{
+				"--int", "0",
+				"--int8", "-8",
+				"--bool",
+				"--disabled=false",
+				"--string", "",
+				"--duration", "1s",
+				"--time", "2017-07-12",
+				"--level", "error",
+				"--strings", "a",
+				"--url", "http://example.com",
+				"80",
+			},
+			check: func(sample *Sample) {
+				assert.EqualValues(t, 0, *sample.Int)
+				assert.EqualValues(t, -8, *sample.Int8)
+				assert.EqualValues(t, true, *sample.Bool)
+				assert.EqualValues(t, false, *sample.Disabled)
+				assert.EqualValues(t, "", *sample.String)
+				assert.EqualValues(t, time.Second, *sample.Duration)
+				assert.EqualValues(t, time.Date(2017, 7, 12, 0, 0, 0, 0, time.UTC), *sample.Time)
+				assert.EqualValues(t, testLevel(2), *sample.Level)
+				assert.EqualValues(t, []string{"a"}, *sample.Strings)
+				assert.EqualValues(t, "example.com", sample.URL.Host)
+				assert.EqualValues(t, 80, *sample.Port)
+			},
+		},
+	} {
+		sample := &Sample{}
+
+		flags, err := FlagsFromStruct(sample)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		context, err := runApp(flags, c.args...)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = FlagsToStruct(context, sample)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		c.check(sample)
+	}
+}
+
+func TestFlagsFromStructUnsupportedPointers(t *testing.T) {
+	type TLSConfig struct {
+		Cert string
+	}
+
+	for _, c := range []struct {
+		sample interface{}
+		err    error
+	}{
+		{
+			&struct {
+				TLS *TLSConfig
+			}{},
+			NewErrUnsupportedType("TLS", "*clistruct.TLSConfig"),
+		},
+		{
+			&struct {
+				DB *struct{ Host string }
+			}{},
+			NewErrUnsupportedType("DB", "*struct { Host string }"),
+		},
+		{
+			&struct {
+				Events *chan string
+			}{},
+			NewErrUnsupportedType("Events", "*chan string"),
+		},
+	} {
+		_, err := FlagsFromStruct(c.sample)
+		assert.EqualValues(t, c.err, err)
+	}
+}
@@ -1,7 +1,9 @@
 package clistruct
 
 import (
+	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -22,13 +24,16 @@ func setStructField(v interface{}, fieldName string, value interface{}) error {
 		return err
 	}
 
-	reflectValue := reflect.ValueOf(value)
+	return setValue(field, value)
+}
 
-	if field.Type() != reflectValue.Type() {
-		return NewErrTypeMistmatch(
-			field.Type().String(),
-			reflectValue.Type().String(),
-		)
+func setValue(field reflect.Value, value interface{}) error {
+	reflectValue, err := convertValue(
+		reflect.ValueOf(value),
+		field.Type(),
+	)
+	if err != nil {
+		return err
 	}
 
 	field.Set(reflectValue)
@@ -36,6 +41,89 @@ func setStructField(v interface{}, fieldName string, value interface{}) error {
 	return nil
 }
 
+// convertValue converts value to the type t if value
+// has the same kind as t, so values could be assigned
+// to the fields of the named types (like `type Port int`).
+// Numbers are converted between the kinds of the same
+// class (signed, unsigned, float) with the range checks,
+// slices are converted element by element.
+func convertValue(value reflect.Value, t reflect.Type) (reflect.Value, error) {
+	var (
+		result reflect.Value
+		elem   reflect.Value
+		err    error
+	)
+
+	switch {
+	case value.Type() == t:
+		return value, nil
+	case t.Kind() == reflect.Interface && value.Type().Implements(t):
+		return value, nil
+	case value.Kind() == reflect.Slice && t.Kind() == reflect.Slice:
+		if value.IsNil() {
+			return reflect.Zero(t), nil
+		}
+
+		result = reflect.MakeSlice(t, value.Len(), value.Len())
+		for n := 0; n < value.Len(); n++ {
+			elem, err = convertValue(value.Index(n), t.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			result.Index(n).Set(elem)
+		}
+
+		return result, nil
+	case kindClass(value.Kind()) != kindClass(t.Kind()):
+	case isOverflow(value, t):
+		return reflect.Value{}, NewErrOverflow(
+			"",
+			fmt.Sprint(value.Interface()),
+			t.String(),
+		)
+	case value.Type().ConvertibleTo(t):
+		return value.Convert(t), nil
+	}
+
+	return reflect.Value{}, NewErrTypeMistmatch(
+		t.String(),
+		value.Type().String(),
+	)
+}
+
+// kindClass returns a kind which represents a class of the numeric
+// kinds: reflect.Int for signed, reflect.Uint for unsigned
+// and reflect.Float64 for floats, other kinds are returned as is.
+func kindClass(kind reflect.Kind) reflect.Kind {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	default:
+		return kind
+	}
+}
+
+// isOverflow reports whether numeric value
+// could not be represented by the type t.
+func isOverflow(value reflect.Value, t reflect.Type) bool {
+	zero := reflect.Zero(t)
+
+	switch kindClass(t.Kind()) {
+	case reflect.Int:
+		return zero.OverflowInt(value.Int())
+	case reflect.Uint:
+		return zero.OverflowUint(value.Uint())
+	case reflect.Float64:
+		return zero.OverflowFloat(value.Float())
+	default:
+		return false
+	}
+}
+
 func checkValue(v interface{}) error {
 	val := reflect.ValueOf(v)
 	if val.Kind() != reflect.Ptr {
@@ -56,6 +144,17 @@ func shouldBeStruct(reflectValue reflect.Value) error {
 	return nil
 }
 
+func shouldBeSlice(reflectValue reflect.Value) error {
+	if reflectValue.Kind() != reflect.Slice {
+		return NewErrInvalidKind(
+			reflect.Slice,
+			reflectValue.Kind(),
+		)
+	}
+
+	return nil
+}
+
 func isStructFieldExported(field reflect.StructField) bool {
 	// From reflect docs:
 	// PkgPath is the package path that qualifies a lower case (unexported)
@@ -78,10 +177,15 @@ func indirectType(reflectType reflect.Type) reflect.Type {
 	return reflectType
 }
 
-func typeName(v interface{}) string {
-	return reflect.TypeOf(v).String()
-}
-
 func getStructFieldTag(field reflect.StructField, name string) string {
 	return strings.TrimSpace(field.Tag.Get(name))
 }
+
+func isStructFieldTagTrue(field reflect.StructField, name string) bool {
+	v, err := strconv.ParseBool(getStructFieldTag(field, name))
+	if err != nil {
+		return false
+	}
+
+	return v
+}
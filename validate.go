@@ -0,0 +1,545 @@
+package clistruct
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	minTag     = "min"
+	maxTag     = "max"
+	oneofTag   = "oneof"
+	patternTag = "pattern"
+	minLenTag  = "minlen"
+	maxLenTag  = "maxlen"
+
+	gtFieldTag         = "gtfield"
+	gteFieldTag        = "gtefield"
+	ltFieldTag         = "ltfield"
+	lteFieldTag        = "ltefield"
+	requiredWithTag    = "required_with"
+	requiredWithoutTag = "required_without"
+)
+
+// Validator is implemented by the structs which
+// should check their values after flags were folded.
+type Validator interface {
+	Validate() error
+}
+
+// tagValidator checks the value of the field against the constraint
+// from the tag, value is an element for slices and maps
+// (except length constraints which are checked for the whole value).
+type tagValidator struct {
+	tag      string
+	usage    string
+	elements bool
+	check    func(field reflect.StructField, value reflect.Value, path string, constraint string, o *options) (bool, error)
+}
+
+// crossFieldValidator checks the value of the field against
+// the value of another field of the same struct named by the tag.
+type crossFieldValidator struct {
+	tag   string
+	usage string
+	check func(value reflect.Value, other reflect.Value) (bool, error)
+}
+
+var (
+	tagValidators = []tagValidator{
+		{tag: minTag, usage: "min", elements: true, check: checkMin},
+		{tag: maxTag, usage: "max", elements: true, check: checkMax},
+		{tag: oneofTag, usage: "one of", elements: true, check: checkOneOf},
+		{tag: patternTag, usage: "pattern", elements: true, check: checkPattern},
+		{tag: minLenTag, usage: "min length", check: checkMinLen},
+		{tag: maxLenTag, usage: "max length", check: checkMaxLen},
+	}
+	crossFieldValidators = []crossFieldValidator{
+		{tag: gtFieldTag, usage: "greater than", check: checkGtField},
+		{tag: gteFieldTag, usage: "greater than or equal to", check: checkGteField},
+		{tag: ltFieldTag, usage: "less than", check: checkLtField},
+		{tag: lteFieldTag, usage: "less than or equal to", check: checkLteField},
+		{tag: requiredWithTag, usage: "required with", check: checkRequiredWith},
+		{tag: requiredWithoutTag, usage: "required without", check: checkRequiredWithout},
+	}
+)
+
+// validateStruct checks the values of the fields in v
+// against the constraints defined with validation tags.
+// It returns ErrValidation with all constraints violated.
+// If constraints are satisfied then Validate method
+// is called for the structs implementing Validator,
+// nested structs are validated before the struct containing them.
+func validateStruct(v interface{}, o *options) error {
+	var (
+		value  = indirectValue(reflect.ValueOf(v))
+		errors []error
+	)
+
+	err := walkStructsBottomUp(
+		value,
+		"",
+		false,
+		o,
+		func(value reflect.Value, prefix string, embedded bool) error {
+			structErrors, err := validateStructFields(value, prefix, o)
+			if err != nil {
+				return err
+			}
+
+			errors = append(errors, structErrors...)
+			return nil
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(errors) > 0 {
+		return NewErrValidation(errors)
+	}
+
+	return walkStructsBottomUp(
+		value,
+		"",
+		false,
+		o,
+		func(value reflect.Value, prefix string, embedded bool) error {
+			if embedded {
+				// Validate method of the embedded struct
+				// is promoted to the struct containing it.
+				return nil
+			}
+
+			validator, ok := value.Addr().Interface().(Validator)
+			if !ok {
+				return nil
+			}
+
+			err := validator.Validate()
+			if err != nil {
+				return NewErrStructValidation(prefix, err)
+			}
+
+			return nil
+		},
+	)
+}
+
+// structWalker is a function which is called by walkStructsBottomUp
+// for each struct, embedded is true for the embedded structs
+// which has `embed:"prefix"` tag (fields of other embedded
+// structs are promoted, so they are not walked as structs).
+type structWalker func(value reflect.Value, prefix string, embedded bool) error
+
+// walkStructsBottomUp calls fn for each nested struct
+// of the struct value and then for the value itself.
+func walkStructsBottomUp(value reflect.Value, prefix string, embedded bool, o *options, fn structWalker) error {
+	var (
+		reflectType = value.Type()
+		err         error
+	)
+
+	for _, field := range structFields(reflectType, o) {
+		if !isStructFieldExported(field) || isCommandStructField(field) {
+			continue
+		}
+		if !isNestedStructField(field, o) || isArgStructField(field) {
+			continue
+		}
+
+		err = walkStructsBottomUp(
+			value.FieldByIndex(field.Index),
+			flagPrefixFromStructField(field, prefix, o),
+			field.Anonymous,
+			o,
+			fn,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return fn(value, prefix, embedded)
+}
+
+// validateStructFields checks the fields of the struct value
+// which are mapped to the flags or arguments
+// (arguments are bound only to the top level struct).
+func validateStructFields(value reflect.Value, prefix string, o *options) ([]error, error) {
+	var (
+		reflectType = value.Type()
+		path        string
+		errors      []error
+	)
+
+	for _, field := range structFields(reflectType, o) {
+		if !isStructFieldExported(field) || isCommandStructField(field) {
+			continue
+		}
+
+		switch {
+		case isArgStructField(field):
+			if prefix != "" {
+				continue
+			}
+			path = argNameFromStructField(field, o)
+		case isNestedStructField(field, o):
+			continue
+		default:
+			path = flagNameFromStructField(field, prefix, o)
+		}
+
+		fieldErrors, err := validateStructField(field, value.FieldByIndex(field.Index), path, o)
+		if err != nil {
+			return nil, err
+		}
+		errors = append(errors, fieldErrors...)
+
+		fieldErrors, err = validateStructFieldWithOthers(field, value, path)
+		if err != nil {
+			return nil, err
+		}
+		errors = append(errors, fieldErrors...)
+	}
+
+	return errors, nil
+}
+
+func validateStructField(field reflect.StructField, value reflect.Value, path string, o *options) ([]error, error) {
+	var (
+		errors []error
+	)
+
+	if isPointerStructField(field, o) {
+		if value.IsNil() {
+			// Value was not set, there is nothing to check.
+			return nil, nil
+		}
+		value = value.Elem()
+	}
+
+	for _, validator := range tagValidators {
+		constraint := getStructFieldTag(field, validator.tag)
+		if constraint == "" {
+			continue
+		}
+
+		values := []reflect.Value{value}
+		if validator.elements {
+			values = elementsFromValue(value)
+		}
+
+		for _, value := range values {
+			ok, err := validator.check(field, value, path, constraint, o)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				errors = append(
+					errors,
+					NewErrConstraint(
+						path,
+						validator.usage,
+						constraint,
+						formatValue(value.Interface()),
+					),
+				)
+			}
+		}
+	}
+
+	return errors, nil
+}
+
+// validateStructFieldWithOthers checks the field value
+// against the values of the other fields of the struct value
+// named by the cross field validation tags.
+func validateStructFieldWithOthers(field reflect.StructField, value reflect.Value, path string) ([]error, error) {
+	var (
+		errors []error
+	)
+
+	for _, validator := range crossFieldValidators {
+		name := getStructFieldTag(field, validator.tag)
+		if name == "" {
+			continue
+		}
+
+		other, ok := value.Type().FieldByName(name)
+		if !ok || !isStructFieldExported(other) {
+			return nil, NewErrUnknownField(field.Name, name)
+		}
+
+		fieldValue := value.FieldByIndex(field.Index)
+		ok, err := validator.check(
+			fieldValue,
+			value.FieldByIndex(other.Index),
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			errors = append(
+				errors,
+				NewErrConstraint(
+					path,
+					validator.usage,
+					name,
+					formatFieldValue(fieldValue),
+				),
+			)
+		}
+	}
+
+	return errors, nil
+}
+
+// formatFieldValue formats the value of the field
+// dereferencing pointers, nil pointers are empty.
+func formatFieldValue(value reflect.Value) string {
+	value = reflect.Indirect(value)
+	if !value.IsValid() {
+		return ""
+	}
+
+	return formatValue(value.Interface())
+}
+
+// usageFromStructField returns a usage text for the field
+// with the constraints from the validation tags appended.
+func usageFromStructField(field reflect.StructField) string {
+	var (
+		usage       = getStructFieldTag(field, usageTag)
+		constraints []string
+	)
+
+	for _, validator := range tagValidators {
+		constraint := getStructFieldTag(field, validator.tag)
+		if constraint == "" {
+			continue
+		}
+		if validator.tag == oneofTag {
+			constraint = strings.Join(splitList(constraint), ", ")
+		}
+
+		constraints = append(
+			constraints,
+			validator.usage+": "+constraint,
+		)
+	}
+
+	if len(constraints) == 0 {
+		return usage
+	}
+
+	return strings.TrimSpace(
+		usage + " (" + strings.Join(constraints, ", ") + ")",
+	)
+}
+
+// elementsFromValue returns the elements of slice or map values
+// and the value itself for other kinds.
+func elementsFromValue(value reflect.Value) []reflect.Value {
+	var (
+		values []reflect.Value
+	)
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for n := 0; n < value.Len(); n++ {
+			values = append(values, value.Index(n))
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			values = append(values, value.MapIndex(key))
+		}
+	default:
+		values = append(values, value)
+	}
+
+	return values
+}
+
+func checkMin(field reflect.StructField, value reflect.Value, path string, constraint string, o *options) (bool, error) {
+	result, err := compareWithConstraint(field, value, path, constraint, o)
+	return result >= 0, err
+}
+
+func checkMax(field reflect.StructField, value reflect.Value, path string, constraint string, o *options) (bool, error) {
+	result, err := compareWithConstraint(field, value, path, constraint, o)
+	return result <= 0, err
+}
+
+func checkOneOf(field reflect.StructField, value reflect.Value, path string, constraint string, o *options) (bool, error) {
+	formatted := formatValue(value.Interface())
+
+	for _, option := range splitList(constraint) {
+		if option == formatted {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func checkPattern(field reflect.StructField, value reflect.Value, path string, constraint string, o *options) (bool, error) {
+	pattern, err := regexp.Compile(constraint)
+	if err != nil {
+		return false, err
+	}
+
+	return pattern.MatchString(formatValue(value.Interface())), nil
+}
+
+func checkMinLen(field reflect.StructField, value reflect.Value, path string, constraint string, o *options) (bool, error) {
+	length, err := lengthConstraint(field, path, constraint)
+	return lengthOfValue(value) >= length, err
+}
+
+func checkMaxLen(field reflect.StructField, value reflect.Value, path string, constraint string, o *options) (bool, error) {
+	length, err := lengthConstraint(field, path, constraint)
+	return lengthOfValue(value) <= length, err
+}
+
+// compareWithConstraint compares numeric value with constraint
+// parsed into the value of the same type, so durations
+// could be constrained like `min:"1s"`, path is the name
+// of the flag (or argument) reported if constraint is malformed.
+func compareWithConstraint(field reflect.StructField, value reflect.Value, path string, constraint string, o *options) (int, error) {
+	bound, err := o.types.valueFromString(field, value.Type(), constraint)
+	if err != nil {
+		return 0, NewErrParse(path, constraint, err)
+	}
+
+	return compareValues(value, bound)
+}
+
+// compareValues compares numeric or time.Time values.
+// It returns -1 if a is less than b, 0 if equal
+// and 1 if a is greater.
+func compareValues(a reflect.Value, b reflect.Value) (int, error) {
+	if a.Type() == timeType && b.Type() == timeType {
+		var (
+			at = a.Interface().(time.Time)
+			bt = b.Interface().(time.Time)
+		)
+		return compare(at.Before(bt), at.After(bt)), nil
+	}
+	if kindClass(a.Kind()) != kindClass(b.Kind()) {
+		return 0, NewErrTypeMistmatch(a.Type().String(), b.Type().String())
+	}
+
+	switch kindClass(a.Kind()) {
+	case reflect.Int:
+		return compare(a.Int() < b.Int(), a.Int() > b.Int()), nil
+	case reflect.Uint:
+		return compare(a.Uint() < b.Uint(), a.Uint() > b.Uint()), nil
+	case reflect.Float64:
+		return compare(a.Float() < b.Float(), a.Float() > b.Float()), nil
+	default:
+		return 0, NewErrInvalidKind(reflect.Int, a.Kind())
+	}
+}
+
+// compareFieldValues compares the values of the fields
+// just like compareValues, pointers are dereferenced.
+// Values are not compared if some of pointers is nil.
+func compareFieldValues(a reflect.Value, b reflect.Value) (int, bool, error) {
+	if a.Kind() == reflect.Ptr {
+		if a.IsNil() {
+			return 0, false, nil
+		}
+		a = a.Elem()
+	}
+	if b.Kind() == reflect.Ptr {
+		if b.IsNil() {
+			return 0, false, nil
+		}
+		b = b.Elem()
+	}
+
+	result, err := compareValues(a, b)
+	return result, true, err
+}
+
+func compare(less bool, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func checkGtField(value reflect.Value, other reflect.Value) (bool, error) {
+	result, ok, err := compareFieldValues(value, other)
+	return !ok || result > 0, err
+}
+
+func checkGteField(value reflect.Value, other reflect.Value) (bool, error) {
+	result, ok, err := compareFieldValues(value, other)
+	return !ok || result >= 0, err
+}
+
+func checkLtField(value reflect.Value, other reflect.Value) (bool, error) {
+	result, ok, err := compareFieldValues(value, other)
+	return !ok || result < 0, err
+}
+
+func checkLteField(value reflect.Value, other reflect.Value) (bool, error) {
+	result, ok, err := compareFieldValues(value, other)
+	return !ok || result <= 0, err
+}
+
+// checkRequiredWith reports whether value is set
+// when the other value is set.
+func checkRequiredWith(value reflect.Value, other reflect.Value) (bool, error) {
+	return other.IsZero() || !value.IsZero(), nil
+}
+
+// checkRequiredWithout reports whether value is set
+// when the other value is not set.
+func checkRequiredWithout(value reflect.Value, other reflect.Value) (bool, error) {
+	return !other.IsZero() || !value.IsZero(), nil
+}
+
+func lengthConstraint(field reflect.StructField, path string, constraint string) (int, error) {
+	length, err := parseInt(field, constraint)
+	if err != nil {
+		return 0, NewErrParse(path, constraint, err)
+	}
+
+	return length.(int), nil
+}
+
+// lengthOfValue returns a number of characters for strings
+// and a number of elements for other values.
+func lengthOfValue(value reflect.Value) int {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len()
+	default:
+		return len(fmt.Sprint(value.Interface()))
+	}
+}
+
+func splitList(v string) []string {
+	var (
+		values []string
+	)
+
+	for _, value := range strings.Split(v, listDelimiter) {
+		values = append(values, strings.TrimSpace(value))
+	}
+
+	return values
+}
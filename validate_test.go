@@ -0,0 +1,311 @@
+package clistruct
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+type testValidated struct {
+	Format  string        `value:"json" oneof:"json, text" usage:"output format"`
+	Name    string        `value:"app" pattern:"^[a-z]+$" minlen:"2" maxlen:"8"`
+	Timeout time.Duration `value:"1s" min:"100ms" max:"1m"`
+	Tags    []string      `maxlen:"2" pattern:"^[a-z]+$"`
+	DB      struct {
+		Port uint16 `value:"5432" min:"1" max:"65535"`
+	}
+	Ratio float64 `arg:"0" value:"0.5" min:"0" max:"1"`
+}
+
+func TestValidateFlags(t *testing.T) {
+	sample := &testValidated{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags, "--tags", "a", "--tags", "b", "--db.port", "80")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	assert.EqualValues(t, []string{"a", "b"}, sample.Tags)
+	assert.EqualValues(t, 80, sample.DB.Port)
+
+	sample = &testValidated{}
+
+	context, err = runApp(
+		flags,
+		"--format", "xml",
+		"--name", "Application",
+		"--timeout", "1h",
+		"--tags", "a",
+		"--tags", "B",
+		"--tags", "c",
+		"--db.port", "0",
+		"2",
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	assert.IsType(t, &ErrValidation{}, err)
+
+	paths := []string{}
+	for _, err := range err.(*ErrValidation).Errors() {
+		paths = append(paths, err.(*ErrConstraint).Path())
+	}
+	assert.EqualValues(
+		t,
+		[]string{"db.port", "format", "name", "name", "timeout", "tags", "tags", "ratio"},
+		paths,
+	)
+}
+
+func TestValidateUsage(t *testing.T) {
+	flags, err := FlagsFromStruct(&testValidated{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	assert.EqualValues(t, "output format (one of: json, text)", flags[0].(cli.StringFlag).Usage)
+	assert.EqualValues(t, "(pattern: ^[a-z]+$, min length: 2, max length: 8)", flags[1].(cli.StringFlag).Usage)
+	assert.EqualValues(t, "(min: 100ms, max: 1m)", flags[2].(cli.DurationFlag).Usage)
+}
+
+func TestValidateInvalidConstraint(t *testing.T) {
+	sample := &struct {
+		Port int `min:"low"`
+	}{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	assert.IsType(t, &ErrParse{}, err)
+
+	for _, c := range []struct {
+		sample interface{}
+		name   string
+	}{
+		{
+			&struct {
+				DBPool struct {
+					MaxConns int `min:"low"`
+				}
+			}{},
+			"db-pool.max-conns",
+		},
+		{
+			&struct {
+				DBPool struct {
+					Name string `minlen:"short"`
+				}
+			}{},
+			"db-pool.name",
+		},
+	} {
+		flags, err = FlagsFromStruct(c.sample, WithNameMapper(KebabCase))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		context, err = runApp(flags)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = FlagsToStruct(context, c.sample, WithNameMapper(KebabCase))
+		assert.IsType(t, &ErrParse{}, err)
+		assert.Contains(t, err.Error(), "for flag '"+c.name+"'")
+	}
+}
+
+type testPool struct {
+	MinConns int `value:"1"`
+	MaxConns int `value:"10" gtefield:"MinConns"`
+}
+
+func (p *testPool) Validate() error {
+	if p.MaxConns > 100 {
+		return errors.New("too many connections")
+	}
+
+	return nil
+}
+
+type testServer struct {
+	TLSCert string `required_with:"TLSKey"`
+	TLSKey  string `required_with:"TLSCert"`
+	Pool    testPool
+}
+
+func (s *testServer) Validate() error {
+	if s.Pool.MaxConns == 50 {
+		return errors.New("unlucky number of connections")
+	}
+
+	return nil
+}
+
+func TestValidateCrossField(t *testing.T) {
+	sample := &testServer{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags, "--tlscert", "cert.pem", "--tlskey", "key.pem")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	sample = &testServer{}
+
+	context, err = runApp(flags, "--tlscert", "cert.pem", "--pool.maxconns", "0")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	assert.IsType(t, &ErrValidation{}, err)
+
+	paths := []string{}
+	for _, err := range err.(*ErrValidation).Errors() {
+		paths = append(paths, err.(*ErrConstraint).Path())
+	}
+	assert.EqualValues(t, []string{"pool.maxconns", "tlskey"}, paths)
+}
+
+func TestValidateStructs(t *testing.T) {
+	flags, err := FlagsFromStruct(&testServer{})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, c := range []struct {
+		args []string
+		path string
+	}{
+		{[]string{"--pool.maxconns", "101"}, "pool"},
+		{[]string{"--pool.maxconns", "50"}, ""},
+	} {
+		sample := &testServer{}
+
+		context, err := runApp(flags, c.args...)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		err = FlagsToStruct(context, sample)
+		if assert.IsType(t, &ErrStructValidation{}, err) {
+			assert.EqualValues(t, c.path, err.(*ErrStructValidation).Path())
+		}
+	}
+}
+
+func TestValidateUnknownField(t *testing.T) {
+	sample := &struct {
+		Max int `gtfield:"Min"`
+	}{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	assert.IsType(t, &ErrUnknownField{}, err)
+}
+
+type testValidatedCommon struct {
+	Port  int `value:"80" min:"1"`
+	calls int
+}
+
+func (c *testValidatedCommon) Validate() error {
+	c.calls++
+	return nil
+}
+
+func TestValidateEmbedded(t *testing.T) {
+	sample := &struct {
+		testValidatedCommon
+	}{}
+
+	flags, err := FlagsFromStruct(sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	context, err := runApp(flags, "--port", "0")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if assert.IsType(t, &ErrValidation{}, err) {
+		assert.EqualValues(t, "port", err.(*ErrValidation).Errors()[0].(*ErrConstraint).Path())
+	}
+
+	context, err = runApp(flags)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	err = FlagsToStruct(context, sample)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Validate is promoted, so it is called once.
+	assert.EqualValues(t, 1, sample.calls)
+}
@@ -0,0 +1,298 @@
+package clistruct
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+var (
+	genericInterface         = reflect.TypeOf((*cli.Generic)(nil)).Elem()
+	textUnmarshalerInterface = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerInterface   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// isGenericType reports whether the pointer to the value of
+// type t could parse a string by itself, because it implements
+// cli.Generic (which has the same methods as flag.Value)
+// or encoding.TextUnmarshaler.
+func isGenericType(t reflect.Type) bool {
+	ptr := reflect.PtrTo(t)
+
+	return ptr.Implements(genericInterface) ||
+		ptr.Implements(textUnmarshalerInterface)
+}
+
+// newGenericValue returns a cli.Generic which writes
+// parsed values straight into the addressable value v.
+func newGenericValue(v reflect.Value) cli.Generic {
+	ptr := v.Addr()
+
+	if ptr.Type().Implements(genericInterface) {
+		return ptr.Interface().(cli.Generic)
+	}
+
+	return &textValue{ptr}
+}
+
+// genericValueTarget returns a value which was written by the cli.Generic
+// constructed with newGenericValue.
+func genericValueTarget(v cli.Generic) interface{} {
+	switch value := v.(type) {
+	case *textValue:
+		return value.ptr.Elem().Interface()
+	default:
+		return reflect.ValueOf(v).Elem().Interface()
+	}
+}
+
+// valueSetter is implemented by the generic values
+// which could take a value of the field as is,
+// it is used to set the default value of the flag.
+type valueSetter interface {
+	setValue(value reflect.Value) error
+}
+
+// envVarSetter is implemented by the generic values
+// which should know the environment variables of the flag,
+// because values taken from them are replaced by the
+// values set on the command line.
+type envVarSetter interface {
+	setEnvVar(envVar string)
+}
+
+// textValue is a cli.Generic adapter for encoding.TextUnmarshaler.
+type textValue struct {
+	ptr reflect.Value
+}
+
+func (v *textValue) Set(s string) error {
+	return v.ptr.Interface().(encoding.TextUnmarshaler).
+		UnmarshalText([]byte(s))
+}
+
+func (v *textValue) String() string {
+	if v.ptr.Type().Implements(textMarshalerInterface) {
+		text, err := v.ptr.Interface().(encoding.TextMarshaler).
+			MarshalText()
+		if err == nil {
+			return string(text)
+		}
+	}
+
+	return fmt.Sprint(v.ptr.Elem().Interface())
+}
+
+// sliceValue is a cli.Generic which appends values parsed
+// with parse to the slice, values could be separated
+// with the list delimiter.
+// Values set by the user replace the default values,
+// unless the field has append tag.
+type sliceValue struct {
+	field  reflect.StructField
+	parse  parser
+	format formatter
+	values reflect.Value
+	set    bool
+}
+
+func newSliceValue(field reflect.StructField, t reflect.Type, parse parser, format formatter) *sliceValue {
+	return &sliceValue{
+		field:  field,
+		parse:  parse,
+		format: format,
+		values: reflect.Zero(t),
+	}
+}
+
+func (v *sliceValue) Set(s string) error {
+	if !v.set && !isStructFieldTagTrue(v.field, appendTag) {
+		v.values = reflect.Zero(v.values.Type())
+	}
+	v.set = true
+
+	for _, item := range strings.Split(s, listDelimiterFromStructField(v.field)) {
+		value, err := v.parse(v.field, strings.TrimSpace(item))
+		if err != nil {
+			return err
+		}
+
+		v.values = reflect.Append(v.values, reflect.ValueOf(value))
+	}
+
+	return nil
+}
+
+func (v *sliceValue) setValue(value reflect.Value) error {
+	values, err := convertValue(value, v.values.Type())
+	if err != nil {
+		return err
+	}
+
+	v.values = values
+	v.set = false
+
+	return nil
+}
+
+func (v *sliceValue) String() string {
+	return formatSlice(v.field, v.values.Interface(), v.format)
+}
+
+// Get returns the slice of parsed values.
+func (v *sliceValue) Get() interface{} {
+	return v.values.Interface()
+}
+
+// scalarValue is a cli.Generic which holds
+// a single value parsed with parse.
+type scalarValue struct {
+	field  reflect.StructField
+	parse  parser
+	format formatter
+	value  interface{}
+}
+
+func newScalarValue(field reflect.StructField, parse parser, format formatter) *scalarValue {
+	return &scalarValue{
+		field:  field,
+		parse:  parse,
+		format: format,
+	}
+}
+
+func (v *scalarValue) Set(s string) error {
+	value, err := v.parse(v.field, s)
+	if err != nil {
+		return err
+	}
+
+	v.value = value
+	return nil
+}
+
+func (v *scalarValue) setValue(value reflect.Value) error {
+	v.value = value.Interface()
+	return nil
+}
+
+func (v *scalarValue) String() string {
+	if v.value == nil {
+		return ""
+	}
+
+	return v.format(v.field, v.value)
+}
+
+// Get returns the parsed value or nil if value was not set.
+func (v *scalarValue) Get() interface{} {
+	return v.value
+}
+
+// mapValue is a cli.Generic which collects key=value pairs
+// into the map, pairs could be separated with the list delimiter.
+// Keys and values are parsed with the handlers from types.
+// Values set by the user replace the default values,
+// values set on the command line replace the values from
+// the environment variable (which is applied first).
+type mapValue struct {
+	field  reflect.StructField
+	types  *registry
+	values reflect.Value
+	set    bool
+	envVar string
+	env    bool
+}
+
+func newMapValue(field reflect.StructField, t reflect.Type, types *registry) *mapValue {
+	return &mapValue{
+		field:  field,
+		types:  types,
+		values: reflect.Zero(t),
+	}
+}
+
+func (v *mapValue) Set(s string) error {
+	if !v.set || v.env {
+		v.values = reflect.MakeMap(v.values.Type())
+		v.set = true
+
+		// First value comes from the environment variable
+		// if it is set, the next one is set on the command line.
+		_, ok := lookupEnvVar(v.envVar)
+		v.env = !v.env && ok
+	}
+
+	for _, pair := range strings.Split(s, listDelimiterFromStructField(v.field)) {
+		kv := strings.SplitN(pair, mapPairDelimiter, 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return NewErrInvalidPair(pair)
+		}
+
+		key, err := v.parse(v.values.Type().Key(), kv[0])
+		if err != nil {
+			return err
+		}
+		if v.values.MapIndex(key).IsValid() {
+			return NewErrDuplicateKey(kv[0])
+		}
+
+		value, err := v.parse(v.values.Type().Elem(), kv[1])
+		if err != nil {
+			return err
+		}
+
+		v.values.SetMapIndex(key, value)
+	}
+
+	return nil
+}
+
+// parse parses the key or value of type t,
+// overflow errors are annotated with the field name.
+func (v *mapValue) parse(t reflect.Type, s string) (reflect.Value, error) {
+	value, err := v.types.valueFromString(v.field, t, strings.TrimSpace(s))
+	if e, ok := err.(*ErrOverflow); ok {
+		return reflect.Value{}, NewErrOverflow(v.field.Name, e.value, e.t)
+	}
+
+	return value, err
+}
+
+func (v *mapValue) setValue(value reflect.Value) error {
+	if value.Type() != v.values.Type() {
+		return NewErrTypeMistmatch(v.values.Type().String(), value.Type().String())
+	}
+
+	v.values = reflect.MakeMap(value.Type())
+	for _, key := range value.MapKeys() {
+		v.values.SetMapIndex(key, value.MapIndex(key))
+	}
+
+	// Default values are replaced by the
+	// first value set by the user.
+	v.set = false
+	v.env = false
+
+	return nil
+}
+
+func (v *mapValue) setEnvVar(envVar string) {
+	v.envVar = envVar
+}
+
+func (v *mapValue) String() string {
+	if v.values.IsNil() {
+		return ""
+	}
+
+	return formatMap(v.field, v.values.Interface())
+}
+
+// Get returns the map of parsed values.
+func (v *mapValue) Get() interface{} {
+	return v.values.Interface()
+}